@@ -16,10 +16,12 @@ import (
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
 	model "github.com/coolray-dev/raydash/models"
-	tool "github.com/coolray-dev/raydash/modules/utils"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/password"
 )
 
-// Login check username and password in request body json and returns access_token and refresh_token
+// Login check username and password in request body json and returns access_token and refresh_token,
+// failed attempts are throttled by username and client ip
 func Login(c *gin.Context) {
 	type Request struct {
 		Username string `json:"username" binding:"required"`
@@ -33,44 +35,109 @@ func Login(c *gin.Context) {
 		})
 		return
 	}
+	ok, locked := reserve(c, json.Username)
+	if !ok {
+		return
+	}
+
 	var user model.User
-	if err := orm.DB.Where("username = ?", json.Username).
-		Where("password = ?", tool.Hash(json.Password)).
-		First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+	if err := orm.DB.Where("username = ?", json.Username).First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		// Hash anyway so response time does not tell whether the user exists
+		password.Hash(json.Password)
+		fail(c, json.Username, nil, locked)
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"error": "Invalid username or password",
 		})
 		return
 	} else if err != nil {
+		// Server errors are not failed attempts of the caller
+		release(c, json.Username)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	accessToken, err := jwt.SignAccessToken(&user)
+
+	// Verify in Go since salted hashes could not be matched in SQL
+	ok, rehash, err := password.Verify(json.Password, user.Password)
+	if err != nil {
+		log.Log.WithError(err).WithField("username", user.Username).Error("Error Verifying Password")
+	}
+	if !ok {
+		fail(c, user.Username, &user, locked)
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"error": "Invalid username or password",
+		})
+		return
+	}
+
+	// Upgrade legacy or outdated hash now that we have the plain password
+	if rehash {
+		if hash, err := password.Hash(json.Password); err != nil {
+			log.Log.WithError(err).Error("Error Hashing Password")
+		} else if err := orm.DB.Model(&user).UpdateColumn("password", hash).Error; err != nil {
+			log.Log.WithError(err).Error("Database Error")
+		}
+	}
+
+	// Users with two-factor authentication, or groups requiring it, get
+	// a challenge token for the second step instead
+	forced, err := user.MFAForced(orm.DB)
 	if err != nil {
+		release(c, user.Username)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	var refreshToken string
-	refreshToken, err = jwt.SignRefreshToken(&user)
+	if user.TOTPEnabled || forced {
+		// Password was right, the second step is throttled on its own
+		release(c, user.Username)
+		mfaToken, err := jwt.SignMFAToken(&user)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"error": err.Error(),
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"mfa_token":      mfaToken,
+			"mfa_enrollment": !user.TOTPEnabled, // enroll through /login/mfa/enroll first
+		})
+		return
+	}
+
+	tokens, err := signTokens(c, &user)
 	if err != nil {
+		release(c, user.Username)
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	c.JSON(http.StatusOK, gin.H{
+	succeed(c, user.Username)
+	c.JSON(http.StatusOK, tokens)
+
+}
+
+// signTokens signs both tokens login responds with
+func signTokens(c *gin.Context, user *model.User) (gin.H, error) {
+	accessToken, err := jwt.SignAccessToken(user)
+	if err != nil {
+		return nil, err
+	}
+	refreshToken, err := jwt.SignRefreshToken(user, c.Request.UserAgent(), c.GetString("remoteIP"))
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
 		"access_token":  accessToken,
 		"refresh_token": refreshToken,
-	})
-
+	}, nil
 }
 
 // Logout checks both accessToken in "Authorization" Header ( use middleware ) and refreshToken in request body json
-// and delete the refreshToken from database
+// and ends the session of the refreshToken
 func Logout(c *gin.Context) {
 	type Request struct {
 		RefreshToken string `json:"refresh_token" binding:"required"`
@@ -88,31 +155,20 @@ func Logout(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := orm.DB.Where("id = ?", uid).First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
-		c.JSON(http.StatusNotFound, gin.H{
-			"error": err.Error(),
-		})
-		return
-	} else if err != nil {
+	result := orm.DB.Where("user_id = ? AND token_hash = ?", uid, models.HashSessionToken(json.RefreshToken)).
+		Delete(&models.Session{})
+	if result.Error != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
+			"error": result.Error.Error(),
 		})
 		return
 	}
-	if _, ok := user.Token[json.RefreshToken]; !ok {
+	if result.RowsAffected == 0 {
 		c.JSON(http.StatusNotFound, gin.H{
 			"error": "RefreshToken not found",
 		})
 		return
 	}
-	delete(user.Token, json.RefreshToken)
-	if err := orm.DB.Save(&user).Error; err != nil {
-		c.JSON(http.StatusInternalServerError, gin.H{
-			"error": err.Error(),
-		})
-		return
-	}
 
 	c.Status(http.StatusNoContent)
 	return
@@ -179,19 +235,25 @@ func RefreshToken(c *gin.Context) {
 
 	now := time.Now()
 
-	// Validate refresh token
-	if user.Token[req.RefreshToken].IsZero() {
+	// Validate refresh token against its session
+	var session models.Session
+	if err := orm.DB.Where("user_id = ? AND token_hash = ?", user.ID, models.HashSessionToken(req.RefreshToken)).
+		First(&session).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Token not found"})
 		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
 	}
-	if now.After(user.Token[req.RefreshToken]) {
+	if session.Expired(now) {
 		c.JSON(http.StatusForbidden, gin.H{"error": "Token expired"})
 		return
 	}
 
-	// Renew its expiry time
-	user.Token[req.RefreshToken] = now.Add(24 * time.Hour)
-	if err := orm.DB.Save(&user).Error; err != nil {
+	// Record its use
+	if err := session.Touch(orm.DB, now, c.GetString("remoteIP")); err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
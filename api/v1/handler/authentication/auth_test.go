@@ -7,6 +7,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/coolray-dev/raydash/modules/casbin"
@@ -14,8 +15,10 @@ import (
 	"github.com/brianvoe/gofakeit/v5"
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/password"
 	"github.com/coolray-dev/raydash/modules/setting"
 	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/coolray-dev/raydash/modules/utils"
 	assertlib "github.com/stretchr/testify/assert"
 )
 
@@ -82,6 +85,31 @@ func TestLogin(t *testing.T) {
 	return
 }
 
+func TestLoginRehash(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	// User registered before argon2id has an unsalted sha256 hash
+	plain := testutils.FakePassword()
+	var legacy models.User
+	gofakeit.Struct(&legacy)
+	legacy.Password = utils.Hash(plain)
+	orm.DB.Create(&legacy)
+
+	body, _ := json.Marshal(map[string]string{"username": legacy.Username, "password": plain})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/login", bytes.NewReader(body))
+	router.ServeHTTP(w, req)
+	assert.Equal(http.StatusOK, w.Code)
+
+	orm.DB.First(&legacy, legacy.ID)
+	assert.True(strings.HasPrefix(legacy.Password, "$argon2id$"))
+	ok, rehash, err := password.Verify(plain, legacy.Password)
+	assert.Nil(err)
+	assert.True(ok)
+	assert.False(rehash)
+}
+
 func TestLogout(t *testing.T) {
 
 	router := testutils.GetRouter()
@@ -161,10 +189,10 @@ func TestLogout(t *testing.T) {
 
 			assert.Equal(c.Status, w.Code)
 
-			if c.Status%100 == 2 { // if status_code starts with 2, which means success
-				orm.DB.Where("id = ?", user.ID).First(&user)
-				_, tokenExists := user.Token[c.RefreshToken]
-				assert.False(tokenExists)
+			if c.Status/100 == 2 { // if status_code starts with 2, which means success
+				var count int64
+				orm.DB.Model(&models.Session{}).Where("token_hash = ?", models.HashSessionToken(c.RefreshToken)).Count(&count)
+				assert.Zero(count)
 			}
 		})
 	}
@@ -0,0 +1,77 @@
+package authentication
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	model "github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/lockout"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/mail"
+	"github.com/coolray-dev/raydash/modules/setting"
+)
+
+// reserve counts an attempt of username as failed up front, so that
+// concurrent attempts are limited as well, or responds 429 with Retry-After
+// when username or client ip has to wait. It returns false when throttled
+// and whether this attempt locks username, which only matters if it fails.
+func reserve(c *gin.Context, username string) (ok bool, locked bool) {
+	wait, locked, err := lockout.Default.Reserve(username, c.GetString("remoteIP"), time.Now())
+	if err != nil {
+		// Do not lock everyone out when the store is down
+		log.Log.WithError(err).Error("Error Reserving Login Attempt")
+		return true, false
+	}
+	if wait <= 0 {
+		return true, locked
+	}
+	c.Header("Retry-After", strconv.Itoa(int(math.Ceil(wait.Seconds()))))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error": "Too many failed attempts, try again later",
+	})
+	return false, false
+}
+
+// fail reports a reserved attempt which failed and locked username,
+// user is nil when not found
+func fail(c *gin.Context, username string, user *model.User, locked bool) {
+	if !locked {
+		return
+	}
+	ip := c.GetString("remoteIP")
+	log.Log.WithField("username", username).WithField("ip", ip).Warn("Account Locked")
+	if user == nil {
+		return
+	}
+	mail.Send(&model.Mail{
+		From:        setting.Config.GetString("mail.from"),
+		To:          user.Email,
+		Subject:     "Account Locked",
+		ContentType: "text/plain",
+		Content: fmt.Sprintf("Your account %s has been locked for %s after too many failed login attempts, the last one from %s. "+
+			"If it was not you, consider changing your password.",
+			user.Username, lockout.Default.Policy.Lockout, ip),
+	})
+}
+
+// release gives back a reserved attempt which turned out valid or could
+// not be checked for a server error
+func release(c *gin.Context, username string) {
+	if err := lockout.Default.Release(username, c.GetString("remoteIP"), time.Now()); err != nil {
+		log.Log.WithError(err).Error("Error Releasing Login Attempt")
+	}
+}
+
+// succeed releases the attempt and forgets failed attempts of username
+// once fully logged in
+func succeed(c *gin.Context, username string) {
+	release(c, username)
+	if err := lockout.Default.Succeed(username); err != nil {
+		log.Log.WithError(err).Error("Error Clearing Login Attempts")
+	}
+}
@@ -0,0 +1,78 @@
+package authentication_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/lockout"
+	"github.com/coolray-dev/raydash/modules/mail"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestLoginLockout(t *testing.T) {
+	assert := assertlib.New(t)
+
+	// No backoff so lockout is reached right away
+	defer func(g *lockout.Guard) { lockout.Default = g }(lockout.Default)
+	lockout.Default = lockout.NewGuard(lockout.NewMemoryStore(), lockout.Policy{
+		Lockout: time.Hour,
+		User:    lockout.Limit{Max: 3},
+	})
+	defer func(c chan *models.Mail) { mail.MailChan = c }(mail.MailChan)
+	mail.MailChan = make(chan *models.Mail, 1)
+
+	plain := testutils.FakePassword()
+	u := mfaUser(plain)
+	wrong := map[string]string{"username": u.Username, "password": plain + "x"}
+	right := map[string]string{"username": u.Username, "password": plain}
+
+	for i := 0; i < 3; i++ {
+		status, _ := postJSON("/v1/login", wrong)
+		assert.Equal(http.StatusUnauthorized, status)
+	}
+	select {
+	case m := <-mail.MailChan:
+		assert.Equal(u.Email, m.To)
+	default:
+		assert.Fail("Lockout mail not sent")
+	}
+
+	// Right password does not help while locked
+	b, _ := json.Marshal(right)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/login", bytes.NewReader(b))
+	testutils.GetRouter().ServeHTTP(w, req)
+	assert.Equal(http.StatusTooManyRequests, w.Code)
+	assert.Equal("3600", w.Header().Get("Retry-After"))
+
+	// Only admins could unlock
+	var admin models.User
+	gofakeit.Struct(&admin)
+	orm.DB.Create(&admin)
+	casbin.Enforcer.AddGroupingPolicy(admin.Username, "group::admin")
+	defer casbin.Enforcer.RemoveGroupingPolicy(admin.Username, "group::admin")
+	casbin.AddDefaultUserPolicy(&u)
+
+	unlock := func(token string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("DELETE", "/v1/users/"+u.Username+"/lock", nil)
+		req.Header.Add("Authorization", "Bearer "+token)
+		testutils.GetRouter().ServeHTTP(w, req)
+		return w.Code
+	}
+	assert.Equal(http.StatusForbidden, unlock(testutils.SignAccessToken(&u)))
+	assert.Equal(http.StatusNoContent, unlock(testutils.SignAccessToken(&admin)))
+
+	status, response := postJSON("/v1/login", right)
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(response, "access_token")
+}
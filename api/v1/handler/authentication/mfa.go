@@ -0,0 +1,157 @@
+package authentication
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	orm "github.com/coolray-dev/raydash/database"
+	model "github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/jwt"
+	"github.com/coolray-dev/raydash/modules/log"
+)
+
+var errInvalidMFAToken = errors.New("Invalid MFA token")
+
+// LoginMFA exchanges the mfa_token from Login and a totp or recovery code for
+// access_token and refresh_token. A user forced to enroll confirms the secret
+// from EnrollMFA here and gets recovery codes as well.
+func LoginMFA(c *gin.Context) {
+	type Request struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+		Code     string `json:"code" binding:"required"`
+	}
+	var json Request
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := checkMFAToken(json.MFAToken)
+	if errors.Is(err, errInvalidMFAToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Codes are far easier to guess than passwords
+	ok, locked := reserve(c, user.Username)
+	if !ok {
+		return
+	}
+
+	now := time.Now()
+	var recoveryCodes []string
+	if user.TOTPEnabled {
+		if !user.CheckSecondFactor(json.Code, now) {
+			fail(c, user.Username, user, locked)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+			return
+		}
+	} else {
+		if recoveryCodes, err = user.ConfirmTOTP(json.Code, now); err != nil {
+			release(c, user.Username)
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		} else if recoveryCodes == nil {
+			fail(c, user.Username, user, locked)
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+			return
+		}
+	}
+	if err := user.SaveTOTP(orm.DB); errors.Is(err, model.ErrCodeUsed) {
+		// Another request used the same code first
+		fail(c, user.Username, user, locked)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid code"})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		release(c, user.Username)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	tokens, err := signTokens(c, user)
+	if err != nil {
+		release(c, user.Username)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if recoveryCodes != nil {
+		tokens["recovery_codes"] = recoveryCodes
+	}
+	succeed(c, user.Username)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// EnrollMFA lets a user whose group requires two-factor authentication
+// enroll during login, the secret is confirmed through LoginMFA
+func EnrollMFA(c *gin.Context) {
+	type Request struct {
+		MFAToken string `json:"mfa_token" binding:"required"`
+	}
+	var json Request
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	user, err := checkMFAToken(json.MFAToken)
+	if errors.Is(err, errInvalidMFAToken) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Replacing an enabled secret takes a logged in user who proves owning it
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication already enabled"})
+		return
+	}
+
+	uri, err := user.EnrollTOTP()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if err := user.SaveTOTP(orm.DB); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"secret": user.TOTPSecret,
+		"uri":    uri,
+	})
+}
+
+// checkMFAToken finds the user a mfa token is signed for
+func checkMFAToken(token string) (*model.User, error) {
+	uid, err := jwt.ParseUID(token)
+	if err != nil {
+		return nil, errInvalidMFAToken
+	}
+
+	var user model.User
+	if err := orm.DB.Where("id = ?", uid).First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errInvalidMFAToken
+	} else if err != nil {
+		return nil, err
+	}
+
+	key, err := user.GetJwtKey()
+	if err != nil {
+		return nil, err
+	}
+	plain, err := jwt.Verify([]byte(token), key)
+	if err != nil || plain.Subject != "MFAChallenge" {
+		return nil, errInvalidMFAToken
+	}
+	return &user, nil
+}
@@ -0,0 +1,158 @@
+package authentication_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/password"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/coolray-dev/raydash/modules/totp"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func postJSON(path string, body interface{}) (int, map[string]interface{}) {
+	b, _ := json.Marshal(body)
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", path, bytes.NewReader(b))
+	testutils.GetRouter().ServeHTTP(w, req)
+	var response map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &response)
+	return w.Code, response
+}
+
+func mfaUser(plain string) models.User {
+	var u models.User
+	gofakeit.Struct(&u)
+	u.Password, _ = password.Hash(plain)
+	orm.DB.Create(&u)
+	u.GetJwtKey() // generate it before tokens are signed from other copies
+	return u
+}
+
+func TestLoginMFA(t *testing.T) {
+	assert := assertlib.New(t)
+
+	plain := testutils.FakePassword()
+	u := mfaUser(plain)
+	u.EnrollTOTP()
+	codes, err := u.ConfirmTOTP(mustCode(u.TOTPSecret, time.Now().Add(-totp.Period*time.Second)), time.Now())
+	assert.Nil(err)
+	assert.Len(codes, models.RecoveryCodeCount)
+	assert.Nil(u.SaveTOTP(orm.DB))
+
+	login := func() string {
+		status, response := postJSON("/v1/login", map[string]string{"username": u.Username, "password": plain})
+		assert.Equal(http.StatusOK, status)
+		assert.NotContains(response, "access_token")
+		assert.Equal(false, response["mfa_enrollment"])
+		return response["mfa_token"].(string)
+	}
+	token := login()
+
+	status, _ := postJSON("/v1/login/mfa", map[string]string{"mfa_token": token, "code": "000000"})
+	assert.Equal(http.StatusUnauthorized, status)
+
+	// An access token is not a challenge token
+	status, _ = postJSON("/v1/login/mfa", map[string]string{"mfa_token": testutils.SignAccessToken(&u), "code": mustCode(u.TOTPSecret, time.Now())})
+	assert.Equal(http.StatusUnauthorized, status)
+
+	code := mustCode(u.TOTPSecret, time.Now())
+	status, response := postJSON("/v1/login/mfa", map[string]string{"mfa_token": token, "code": code})
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(response, "access_token")
+	assert.Contains(response, "refresh_token")
+
+	// Codes work only once
+	status, _ = postJSON("/v1/login/mfa", map[string]string{"mfa_token": login(), "code": code})
+	assert.Equal(http.StatusUnauthorized, status)
+
+	// So do recovery codes
+	status, _ = postJSON("/v1/login/mfa", map[string]string{"mfa_token": login(), "code": codes[0]})
+	assert.Equal(http.StatusOK, status)
+	status, _ = postJSON("/v1/login/mfa", map[string]string{"mfa_token": login(), "code": codes[0]})
+	assert.Equal(http.StatusUnauthorized, status)
+}
+
+func TestSaveTOTPConcurrent(t *testing.T) {
+	assert := assertlib.New(t)
+
+	u := mfaUser(testutils.FakePassword())
+	u.EnrollTOTP()
+	codes, err := u.ConfirmTOTP(mustCode(u.TOTPSecret, time.Now().Add(-totp.Period*time.Second)), time.Now())
+	assert.Nil(err)
+	assert.Nil(u.SaveTOTP(orm.DB))
+
+	// Two requests load the user before either saves
+	load := func() (first, second models.User) {
+		orm.DB.First(&first, u.ID)
+		orm.DB.First(&second, u.ID)
+		return
+	}
+
+	now := time.Now()
+	code := mustCode(u.TOTPSecret, now)
+	first, second := load()
+	assert.True(first.CheckSecondFactor(code, now))
+	assert.True(second.CheckSecondFactor(code, now))
+	assert.Nil(first.SaveTOTP(orm.DB))
+	assert.Equal(models.ErrCodeUsed, second.SaveTOTP(orm.DB))
+
+	first, second = load()
+	assert.True(first.CheckSecondFactor(codes[0], now))
+	assert.True(second.CheckSecondFactor(codes[0], now))
+	assert.Nil(first.SaveTOTP(orm.DB))
+	assert.Equal(models.ErrCodeUsed, second.SaveTOTP(orm.DB))
+
+	var saved models.User
+	orm.DB.First(&saved, u.ID)
+	assert.False(saved.CheckSecondFactor(codes[0], now))
+	assert.True(saved.CheckSecondFactor(codes[1], now))
+}
+
+func TestLoginMFAForced(t *testing.T) {
+	assert := assertlib.New(t)
+
+	plain := testutils.FakePassword()
+	u := mfaUser(plain)
+	group := models.Group{Name: gofakeit.Word(), RequireMFA: true}
+	orm.DB.Create(&group)
+	orm.DB.Model(&group).Association("Users").Append(&u)
+
+	status, response := postJSON("/v1/login", map[string]string{"username": u.Username, "password": plain})
+	assert.Equal(http.StatusOK, status)
+	assert.NotContains(response, "access_token")
+	assert.Equal(true, response["mfa_enrollment"])
+	token := response["mfa_token"].(string)
+
+	status, response = postJSON("/v1/login/mfa/enroll", map[string]string{"mfa_token": token})
+	assert.Equal(http.StatusOK, status)
+	secret := response["secret"].(string)
+	assert.Contains(response["uri"], secret)
+
+	status, response = postJSON("/v1/login/mfa", map[string]string{"mfa_token": token, "code": mustCode(secret, time.Now())})
+	assert.Equal(http.StatusOK, status)
+	assert.Contains(response, "access_token")
+	assert.Len(response["recovery_codes"], models.RecoveryCodeCount)
+
+	orm.DB.First(&u, u.ID)
+	assert.True(u.TOTPEnabled)
+
+	// Enrolled secret could not be replaced with a challenge token alone
+	status, _ = postJSON("/v1/login/mfa/enroll", map[string]string{"mfa_token": token})
+	assert.Equal(http.StatusConflict, status)
+}
+
+func mustCode(secret string, t time.Time) string {
+	code, err := totp.Code(secret, totp.Step(t))
+	if err != nil {
+		panic(err)
+	}
+	return code
+}
@@ -6,6 +6,7 @@ import (
 
 	"github.com/coolray-dev/raydash/modules/casbin"
 
+	"github.com/coolray-dev/raydash/modules/password"
 	"github.com/coolray-dev/raydash/modules/utils"
 
 	orm "github.com/coolray-dev/raydash/database"
@@ -32,9 +33,15 @@ func Register(c *gin.Context) {
 		return
 	}
 
+	hash, err := password.Hash(json.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
 	var user model.User
 	user.Username = json.Username
-	user.Password = utils.Hash(json.Password)
+	user.Password = hash
 	user.Email = json.Email
 	user.UUID = uuid.New().String()
 	user.CurrentTraffic = 0
@@ -11,8 +11,8 @@ import (
 	"github.com/brianvoe/gofakeit/v5"
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/password"
 	"github.com/coolray-dev/raydash/modules/testutils"
-	"github.com/coolray-dev/raydash/modules/utils"
 	"github.com/google/uuid"
 	assertlib "github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
@@ -116,10 +116,13 @@ func TestRegister(t *testing.T) {
 				assert.False(
 					errors.Is(orm.DB.Where("username = ?", c.Username).
 						Where("email = ?", c.Email).
-						Where("password = ?", utils.Hash(c.Password)).
 						First(&user).
 						Error, gorm.ErrRecordNotFound),
 				)
+				ok, rehash, err := password.Verify(c.Password, user.Password)
+				assert.Nil(err)
+				assert.True(ok)
+				assert.False(rehash)
 
 				_, UUIDParseErr = uuid.Parse(user.UUID)
 				assert.Nil(UUIDParseErr)
@@ -6,7 +6,8 @@ import (
 
 	orm "github.com/coolray-dev/raydash/database"
 	model "github.com/coolray-dev/raydash/models"
-	"github.com/coolray-dev/raydash/modules/utils"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/password"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -35,8 +36,20 @@ func ResetPassword(c *gin.Context) {
 		return
 	}
 
-	orm.DB.Model(fp.User).Update("password", utils.Hash(json.Password))
+	hash, err := password.Hash(json.Password)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
+	orm.DB.Model(fp.User).Update("password", hash)
 	orm.DB.Unscoped().Delete(&fp)
 
+	// Whoever knew the old password should not stay logged in
+	if err := model.RevokeSessions(orm.DB, fp.User.ID); err != nil {
+		log.Log.WithError(err).Error("Database Error")
+	}
+
 	c.Status(http.StatusNoContent)
 }
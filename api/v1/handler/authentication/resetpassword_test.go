@@ -11,8 +11,8 @@ import (
 	"github.com/brianvoe/gofakeit/v5"
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/password"
 	"github.com/coolray-dev/raydash/modules/testutils"
-	"github.com/coolray-dev/raydash/modules/utils"
 	assertlib "github.com/stretchr/testify/assert"
 	"gorm.io/gorm"
 )
@@ -34,6 +34,14 @@ func TestResetpassword(t *testing.T) {
 
 	//defer orm.DB.Rollback()
 
+	// Reset logs the user out everywhere
+	testutils.SignRefreshToken(fp1.User)
+	defer func() {
+		var count int64
+		orm.DB.Model(&models.Session{}).Where("user_id = ?", fp1.User.ID).Count(&count)
+		assertlib.Zero(t, count)
+	}()
+
 	cases := []struct {
 		Name     string
 		Token    string
@@ -82,7 +90,9 @@ func TestResetpassword(t *testing.T) {
 
 				var user models.User
 				orm.DB.Where("id = ?", c.Record.UserID).First(&user)
-				assert.Equal(utils.Hash(c.Password), user.Password)
+				ok, _, err := password.Verify(c.Password, user.Password)
+				assert.Nil(err)
+				assert.True(ok)
 			} else {
 				json.Unmarshal([]byte(w.Body.String()), &response)
 				assert.NotEqual("", response["error"])
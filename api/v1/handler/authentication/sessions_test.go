@@ -0,0 +1,104 @@
+package authentication_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestSessions(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	plain := testutils.FakePassword()
+	u := mfaUser(plain)
+	casbin.AddDefaultUserPolicy(&u)
+
+	request := func(method, path, token, userAgent string, body interface{}) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(b))
+		if token != "" {
+			req.Header.Add("Authorization", "Bearer "+token)
+		}
+		req.Header.Set("User-Agent", userAgent)
+		req.Header.Set("X-Forwarded-For", "203.0.113.1")
+		req.RemoteAddr = "192.0.2.10:4321"
+		router.ServeHTTP(w, req)
+		return w
+	}
+	login := func(userAgent string) string {
+		w := request("POST", "/v1/login", "", userAgent, map[string]string{"username": u.Username, "password": plain})
+		assert.Equal(http.StatusOK, w.Code)
+		var response map[string]string
+		json.Unmarshal(w.Body.Bytes(), &response)
+		return response["refresh_token"]
+	}
+	refresh := func(token string) int {
+		return request("POST", "/v1/refresh", "", "", map[string]string{"refresh_token": token}).Code
+	}
+	sessionsPath := "/v1/users/" + u.Username + "/sessions"
+
+	phone := login("Phone")
+	laptop := login("Laptop")
+	assert.Equal(http.StatusOK, refresh(phone))
+
+	token := testutils.SignAccessToken(&u)
+	w := request("GET", sessionsPath, token, "", nil)
+	assert.Equal(http.StatusOK, w.Code)
+	var response struct {
+		Sessions []models.Session `json:"sessions"`
+	}
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Len(response.Sessions, 2)
+	assert.Equal("Phone", response.Sessions[0].UserAgent, "most recently used first")
+	assert.NotContains(w.Body.String(), phone)
+	assert.Equal("192.0.2.10", response.Sessions[0].IP, "forwarded header is not trusted")
+
+	// Refreshing does not outlive the refresh token
+	expireAt := response.Sessions[0].ExpireAt
+	assert.Equal(http.StatusOK, refresh(phone))
+	w = request("GET", sessionsPath, token, "", nil)
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.True(expireAt.Equal(response.Sessions[0].ExpireAt))
+
+	// Revoke one
+	w = request("DELETE", fmt.Sprintf("%s/%d", sessionsPath, response.Sessions[0].ID), token, "", nil)
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal(http.StatusForbidden, refresh(phone))
+	assert.Equal(http.StatusOK, refresh(laptop))
+
+	// Sessions of others could not be revoked by id
+	other := models.Session{UserID: user.ID, TokenHash: models.HashSessionToken(gofakeit.UUID())}
+	assert.Nil(orm.DB.Create(&other).Error)
+	w = request("DELETE", fmt.Sprintf("%s/%d", sessionsPath, other.ID), token, "", nil)
+	assert.Equal(http.StatusNotFound, w.Code)
+
+	// Changing password revokes all
+	w = request("PUT", "/v1/users/"+u.Username+"/password", token, "", map[string]string{"password": plain + "x", "new_password": plain})
+	assert.Equal(http.StatusBadRequest, w.Code)
+	login("Tablet")
+	w = request("PUT", "/v1/users/"+u.Username+"/password", token, "", map[string]string{"password": plain, "new_password": plain + "x"})
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal(http.StatusForbidden, refresh(laptop))
+	w = request("GET", sessionsPath, token, "", nil)
+	assert.Nil(json.Unmarshal(w.Body.Bytes(), &response))
+	assert.Empty(response.Sessions)
+
+	// So does revoking all
+	plain += "x"
+	tablet := login("Tablet")
+	w = request("DELETE", sessionsPath, token, "", nil)
+	assert.Equal(http.StatusNoContent, w.Code)
+	assert.Equal(http.StatusForbidden, refresh(tablet))
+}
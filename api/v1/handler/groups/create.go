@@ -2,7 +2,6 @@ package groups
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/gin-gonic/gin"
 
@@ -46,7 +45,7 @@ func Create(c *gin.Context) {
 	}
 
 	// Deal With Access Control
-	casbin.Enforcer.AddPolicy("group::"+group.Name, "/*/groups/"+strconv.Itoa(int(group.ID))+"*", "*")
+	casbin.AddGroupPolicy(&group)
 
 	c.JSON(http.StatusOK, createResponse{
 		Group: group,
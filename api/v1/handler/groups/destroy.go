@@ -2,12 +2,14 @@ package groups
 
 import (
 	"net/http"
-	"strconv"
 
 	orm "github.com/coolray-dev/raydash/database"
 	model "github.com/coolray-dev/raydash/models"
 	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/provision"
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
 type destroyResponse struct {
@@ -38,22 +40,32 @@ func Destroy(c *gin.Context) {
 	}
 	var group model.Group
 	group.ID = gid
-	if err = orm.DB.Where("id = ?", gid).First(&group).Error; err != nil {
+	if err = orm.DB.Preload("Nodes").Where("id = ?", gid).First(&group).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
-	oldname := group.Name
-	if err = orm.DB.Delete(&group).Error; err != nil {
+
+	// Take nodes out first so members lose services no other group gives
+	err = orm.DB.Transaction(func(tx *gorm.DB) error {
+		for _, n := range group.Nodes {
+			if err := provision.Unassign(tx, &group, n); err != nil {
+				return err
+			}
+		}
+		return tx.Delete(&group).Error
+	})
+	if err != nil {
+		log.Log.WithError(err).Error("Database Error")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
 		return
 	}
 
-	// Remove Group from casbin
-	casbin.Enforcer.RemovePolicy("group::"+oldname, "/*/groups/"+strconv.Itoa(int(group.ID))+"*", "*")
+	// Remove group, its node policies and members from casbin
+	casbin.RemoveGroup(&group)
 
 	c.JSON(http.StatusOK, destroyResponse{
 		Group: "",
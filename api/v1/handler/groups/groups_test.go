@@ -0,0 +1,177 @@
+package groups_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/gin-gonic/gin"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+// A group with a member, two nodes and an admin to manage them
+var group models.Group
+var nodeA, nodeB models.Node
+var user, admin models.User
+
+func TestMain(m *testing.M) {
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	nodeA = models.Node{Name: gofakeit.Word(), Host: gofakeit.DomainName()}
+	nodeB = models.Node{Name: gofakeit.Word(), Host: gofakeit.DomainName()}
+	orm.DB.Create(&nodeA)
+	orm.DB.Create(&nodeB)
+
+	gofakeit.Struct(&user)
+	orm.DB.Create(&user)
+	casbin.AddDefaultUserPolicy(&user)
+	gofakeit.Struct(&admin)
+	orm.DB.Create(&admin)
+	casbin.Enforcer.AddGroupingPolicy(admin.Username, "group::admin")
+
+	group = models.Group{Name: gofakeit.Word(), Users: []*models.User{&user}}
+	orm.DB.Create(&group)
+	casbin.AddGroupPolicy(&group)
+	casbin.Enforcer.AddGroupingPolicy(user.Username, "group::"+group.Name)
+
+	code := m.Run()
+	casbin.Enforcer.RemoveGroupingPolicy(admin.Username, "group::admin")
+	casbin.Enforcer.RemoveGroupingPolicy(user.Username, "group::"+group.Name)
+	os.Exit(code)
+}
+
+func TestNodes(t *testing.T) {
+	router := testutils.GetRouter()
+	groupPath := "/v1/groups/" + strconv.FormatUint(group.ID, 10) + "/nodes"
+	adminToken := testutils.SignAccessToken(&admin)
+	userToken := testutils.SignAccessToken(&user)
+
+	request := func(method, path, token string, body gin.H) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(b))
+		req.Header.Add("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	nodeIDs := func(w *httptest.ResponseRecorder) []uint64 {
+		var res struct {
+			Nodes []models.Node `json:"nodes"`
+		}
+		json.Unmarshal(w.Body.Bytes(), &res)
+		ids := []uint64{}
+		for _, n := range res.Nodes {
+			ids = append(ids, n.ID)
+		}
+		return ids
+	}
+	serviceNodes := func() []uint64 {
+		nids := []uint64{}
+		orm.DB.Model(&models.Service{}).Where("user_id = ?", user.ID).Order("node_id").Pluck("node_id", &nids)
+		return nids
+	}
+	nodePath := func(n models.Node) string {
+		return "/v1/nodes/" + strconv.FormatUint(n.ID, 10)
+	}
+
+	cases := []struct {
+		Name     string
+		Method   string
+		Path     string
+		Token    string
+		Body     gin.H
+		Status   int
+		Nodes    []uint64 // of group after the request
+		Services []uint64 // nodes the member has services on
+	}{
+		{"Member could not assign", "PATCH", groupPath, userToken, gin.H{"nids": []uint64{nodeA.ID}}, http.StatusForbidden, []uint64{}, []uint64{}},
+		{"Unknown node", "PUT", groupPath, adminToken, gin.H{"nids": []uint64{nodeA.ID, nodeB.ID + 1000}}, http.StatusNotFound, []uint64{}, []uint64{}},
+		{"Missing nids", "PUT", groupPath, adminToken, gin.H{}, http.StatusBadRequest, []uint64{}, []uint64{}},
+		{"Append", "PATCH", groupPath, adminToken, gin.H{"nids": []uint64{nodeA.ID}}, http.StatusOK, []uint64{nodeA.ID}, []uint64{nodeA.ID}},
+		{"Append again", "PATCH", groupPath, adminToken, gin.H{"nids": []uint64{nodeA.ID, nodeB.ID}}, http.StatusOK, []uint64{nodeA.ID, nodeB.ID}, []uint64{nodeA.ID, nodeB.ID}},
+		{"Replace", "PUT", groupPath, adminToken, gin.H{"nids": []uint64{nodeB.ID}}, http.StatusOK, []uint64{nodeB.ID}, []uint64{nodeB.ID}},
+		{"Remove not in group", "DELETE", groupPath + "/" + strconv.FormatUint(nodeA.ID, 10), adminToken, nil, http.StatusNotFound, []uint64{nodeB.ID}, []uint64{nodeB.ID}},
+		{"Remove", "DELETE", groupPath + "/" + strconv.FormatUint(nodeB.ID, 10), adminToken, nil, http.StatusOK, []uint64{}, []uint64{}},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			assert.Equal(c.Status, request(c.Method, c.Path, c.Token, c.Body).Code)
+			assert.ElementsMatch(c.Nodes, nodeIDs(request("GET", groupPath, userToken, nil)))
+			assert.Equal(c.Services, serviceNodes())
+
+			// Members may see exactly the nodes of their group
+			for _, n := range []models.Node{nodeA, nodeB} {
+				status := http.StatusForbidden
+				for _, nid := range c.Nodes {
+					if nid == n.ID {
+						status = http.StatusOK
+					}
+				}
+				assert.Equal(status, request("GET", nodePath(n), userToken, nil).Code)
+			}
+		})
+	}
+}
+
+func TestRenameAndDestroy(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+	adminToken := testutils.SignAccessToken(&admin)
+
+	var member models.User
+	gofakeit.Struct(&member)
+	orm.DB.Create(&member)
+	casbin.AddDefaultUserPolicy(&member)
+	memberToken := testutils.SignAccessToken(&member)
+
+	g := models.Group{Name: gofakeit.Word() + "-old", Users: []*models.User{&member}}
+	orm.DB.Create(&g)
+	casbin.AddGroupPolicy(&g)
+	casbin.Enforcer.AddGroupingPolicy(member.Username, "group::"+g.Name)
+
+	request := func(method, path, token string, body gin.H) int {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(b))
+		req.Header.Add("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w.Code
+	}
+	groupPath := "/v1/groups/" + strconv.FormatUint(g.ID, 10)
+	nodePath := "/v1/nodes/" + strconv.FormatUint(nodeA.ID, 10)
+	services := func() int64 {
+		var count int64
+		orm.DB.Model(&models.Service{}).Where("user_id = ?", member.ID).Count(&count)
+		return count
+	}
+
+	assert.Equal(http.StatusOK, request("PATCH", groupPath+"/nodes", adminToken, gin.H{"nids": []uint64{nodeA.ID}}))
+	assert.Equal(http.StatusOK, request("GET", nodePath, memberToken, nil))
+	assert.Equal(int64(1), services())
+
+	// Members keep access to the group and its nodes under the new name
+	assert.Equal(http.StatusOK, request("PATCH", groupPath, adminToken, gin.H{"groupname": g.Name + "-new"}))
+	assert.Equal(http.StatusOK, request("GET", groupPath, memberToken, nil))
+	assert.Equal(http.StatusOK, request("GET", nodePath, memberToken, nil))
+	assert.Empty(casbin.Enforcer.GetFilteredPolicy(0, "group::"+g.Name))
+
+	// Former members lose the group, its nodes and their services on them
+	assert.Equal(http.StatusOK, request("DELETE", groupPath, adminToken, nil))
+	assert.Equal(http.StatusForbidden, request("GET", groupPath, memberToken, nil))
+	assert.Equal(http.StatusForbidden, request("GET", nodePath, memberToken, nil))
+	assert.Equal(int64(0), services())
+	assert.Empty(casbin.Enforcer.GetFilteredGroupingPolicy(1, "group::"+g.Name+"-new"))
+}
@@ -0,0 +1,212 @@
+package groups
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/provision"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type nodesResponse struct {
+	Nodes []*models.Node `json:"nodes"`
+}
+
+type nodesRequest struct {
+	NIDs []uint64 `json:"nids" binding:"required"`
+}
+
+// Nodes return all nodes of a group
+//
+// Nodes godoc
+// @Summary Get Group Nodes
+// @Description List out all nodes assigned to a group
+// @ID groups.Nodes
+// @Security ApiKeyAuth
+// @Tags Groups
+// @Accept  json
+// @Produce  json
+// @Param gid path uint true "Group ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} nodesResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /groups/{gid}/nodes [get]
+func Nodes(c *gin.Context) {
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, nodesResponse{
+		Nodes: group.Nodes,
+	})
+	return
+}
+
+// SetNodes replace nodes of a group, members get services on added nodes
+// and lose services on removed nodes no other group gives
+//
+// SetNodes godoc
+// @Summary Set Group Nodes
+// @Description Replace nodes assigned to a group
+// @ID groups.SetNodes
+// @Security ApiKeyAuth
+// @Tags Groups
+// @Accept  json
+// @Produce  json
+// @Param gid path uint true "Group ID"
+// @Param nodes body nodesRequest true "Node IDs"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} nodesResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /groups/{gid}/nodes [put]
+func SetNodes(c *gin.Context) {
+	changeNodes(c, true)
+}
+
+// AppendNodes add nodes to a group, members get services on them
+//
+// AppendNodes godoc
+// @Summary Append Group Nodes
+// @Description Assign nodes to a group
+// @ID groups.AppendNodes
+// @Security ApiKeyAuth
+// @Tags Groups
+// @Accept  json
+// @Produce  json
+// @Param gid path uint true "Group ID"
+// @Param nodes body nodesRequest true "Node IDs"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} nodesResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /groups/{gid}/nodes [patch]
+func AppendNodes(c *gin.Context) {
+	changeNodes(c, false)
+}
+
+// RemoveNode remove a node from a group, members lose services on it
+// if no other group gives it
+//
+// RemoveNode godoc
+// @Summary Remove Group Node
+// @Description Unassign a node from a group
+// @ID groups.RemoveNode
+// @Security ApiKeyAuth
+// @Tags Groups
+// @Accept  json
+// @Produce  json
+// @Param gid path uint true "Group ID"
+// @Param nid path uint true "Node ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} nodesResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /groups/{gid}/nodes/{nid} [delete]
+func RemoveNode(c *gin.Context) {
+	nid, err := strconv.ParseUint(c.Param("nid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid NID: " + err.Error()})
+		return
+	}
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	for _, n := range group.Nodes {
+		if n.ID == nid {
+			applyNodes(c, group, nil, provision.GroupAssignments(group, []*models.Node{n}))
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Node not in group"})
+}
+
+// changeNodes adds nodes in request to group, nodes not in request are removed if replace
+func changeNodes(c *gin.Context, replace bool) {
+	var json nodesRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	group, ok := findGroup(c)
+	if !ok {
+		return
+	}
+
+	nodes, err := provision.FindNodes(orm.DB, json.NIDs)
+	if errors.Is(err, provision.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	add, remove := provision.Diff(
+		provision.GroupAssignments(group, group.Nodes),
+		provision.GroupAssignments(group, nodes),
+		replace)
+	applyNodes(c, group, add, remove)
+}
+
+// applyNodes changes nodes of group and responds with the nodes after it
+func applyNodes(c *gin.Context, group *models.Group, add, remove []provision.Assignment) {
+	err := provision.SetAssignments(orm.DB, add, remove)
+	if errors.Is(err, models.ErrPortInUse) || errors.Is(err, models.ErrNoFreePort) {
+		log.Log.WithError(err).WithField("groupID", group.ID).Warn("Error Provisioning Services")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var nodes []*models.Node
+	if err := orm.DB.Model(group).Association("Nodes").Find(&nodes); err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, nodesResponse{
+		Nodes: nodes,
+	})
+	return
+}
+
+// findGroup loads the group in url with its nodes,
+// response is written when it fails so caller should simply return
+func findGroup(c *gin.Context) (*models.Group, bool) {
+	gid, err := parseGID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	var group models.Group
+	if err := orm.DB.Preload("Nodes").First(&group, gid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &group, true
+}
@@ -2,7 +2,6 @@ package groups
 
 import (
 	"net/http"
-	"strconv"
 
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
@@ -45,7 +44,7 @@ func Update(c *gin.Context) {
 		})
 		return
 	}
-	oldname := group.Name
+	old := group
 	if err = c.ShouldBindJSON(&group); err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -58,10 +57,9 @@ func Update(c *gin.Context) {
 		return
 	}
 
-	// Remove old policy and add new policy
-	if oldname != group.Name {
-		casbin.Enforcer.RemovePolicy("group::"+oldname, "/*/groups/"+strconv.Itoa(int(group.ID))+"*", "*")
-		casbin.Enforcer.AddPolicy("group::"+group.Name, "/*/groups/"+strconv.Itoa(int(group.ID))+"*", "*")
+	// Move policies and members to the new name
+	if old.Name != group.Name {
+		casbin.RenameGroup(old.Name, &group)
 	}
 
 	c.JSON(http.StatusOK, updateResponse{
@@ -7,6 +7,8 @@ import (
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
 	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/provision"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -57,7 +59,8 @@ func Users(c *gin.Context) {
 	return
 }
 
-// AppendUser add a user to specific group
+// AppendUser add a user to specific group and create services for the user
+// on nodes of the group
 //
 // AppendUser godoc
 // @Summary Append User
@@ -73,6 +76,7 @@ func Users(c *gin.Context) {
 // @Success 200 {object} usersResponse
 // @Failure 403 {object} handler.ErrorResponse
 // @Failure 500 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
 // @Router /groups/{gid}/users [patch]
 func AppendUser(c *gin.Context) {
 	var group models.Group
@@ -107,8 +111,19 @@ func AppendUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	group.Users = append(group.Users, &user)
-	if err = orm.DB.Save(&group).Error; err != nil {
+	// Join the group and get services on its nodes at once
+	err = orm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&group).Association("Users").Append(&user); err != nil {
+			return err
+		}
+		return provision.AppendUser(tx, &group, &user)
+	})
+	if errors.Is(err, models.ErrPortInUse) || errors.Is(err, models.ErrNoFreePort) {
+		log.Log.WithError(err).WithField("username", user.Username).Warn("Error Provisioning Services")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -116,7 +131,7 @@ func AppendUser(c *gin.Context) {
 	}
 
 	// Add User to Group in casbin
-	casbin.Enforcer.AddGroupingPolicy(user.Username, group.Name)
+	casbin.Enforcer.AddGroupingPolicy(user.Username, "group::"+group.Name)
 
 	c.JSON(http.StatusOK, usersResponse{
 		Users: group.Users,
@@ -124,7 +139,8 @@ func AppendUser(c *gin.Context) {
 	return
 }
 
-// RemoveUser remove a user from a specific group
+// RemoveUser remove a user from a specific group and delete services of the user
+// on nodes no longer reachable through other groups
 //
 // RemoveUser godoc
 // @Summary Remove User
@@ -169,15 +185,15 @@ func RemoveUser(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
-	var index int
-	for i := range group.Users {
-		if group.Users[i].ID == user.ID {
-			index = i
-			break
+	// Leave the group and drop services on nodes no other group gives
+	err = orm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&group).Association("Users").Delete(&user); err != nil {
+			return err
 		}
-	}
-	group.Users = append(group.Users[:index], group.Users[index+1:]...)
-	if err = orm.DB.Save(&group).Error; err != nil {
+		return provision.RemoveUser(tx, &group, &user)
+	})
+	if err != nil {
+		log.Log.WithError(err).Error("Database Error")
 		c.JSON(http.StatusInternalServerError, gin.H{
 			"error": err.Error(),
 		})
@@ -185,7 +201,7 @@ func RemoveUser(c *gin.Context) {
 	}
 
 	// Remove User from Group in casbin
-	casbin.Enforcer.RemoveGroupingPolicy(username, group.Name)
+	casbin.Enforcer.RemoveGroupingPolicy(username, "group::"+group.Name)
 
 	c.JSON(http.StatusOK, usersResponse{
 		Users: group.Users,
@@ -0,0 +1,91 @@
+package nodes
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/v2ray"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Config renders the v2ray or xray config of a node with one client per service,
+// services of suspended users are left out
+//
+// Config godoc
+// @Summary Node Config
+// @Description Render v2ray/xray config of a node, an ETag is returned so agents could poll with If-None-Match
+// @ID Nodes.Config
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param format query string false "v2ray (default) or xray"
+// @Param If-None-Match header string false "ETag of the config agent has"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} v2ray.Config
+// @Success 304 {string} string
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/config [get]
+func Config(c *gin.Context) {
+
+	// Get Node ID
+	nid, err := parseNID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Node ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var node models.Node
+	if err := orm.DB.Preload("Services", models.ActiveServices).Where("id = ?", nid).First(&node).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("nodeID", nid).Warn("Node Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	format := c.Query("format")
+	if format == "" {
+		format = v2ray.FormatV2ray
+	}
+	cfg, err := v2ray.Build(&node, node.Services, format)
+	if errors.Is(err, v2ray.ErrUnknownFormat) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).WithField("nodeID", nid).Error("Error Building Node Config")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	body, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		log.Log.WithError(err).Error("Error Encoding Node Config")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Same config always gives the same ETag
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:]) + `"`
+	c.Header("ETag", etag)
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+	return
+}
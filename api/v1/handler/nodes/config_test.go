@@ -0,0 +1,58 @@
+package nodes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestConfig(t *testing.T) {
+	router := testutils.GetRouter()
+
+	cases := []struct {
+		Name   string
+		Token  string
+		Format string
+		Status int
+	}{
+		{"V2Ray", node.AccessToken, "", http.StatusOK},
+		{"Xray", node.AccessToken, "xray", http.StatusOK},
+		{"Unknown format", node.AccessToken, "clash", http.StatusBadRequest},
+		{"Other node", otherNode.AccessToken, "", http.StatusForbidden},
+		{"Anonymous", "", "", http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/nodes/"+strconv.FormatUint(node.ID, 10)+"/config?format="+c.Format, nil)
+			if c.Token != "" {
+				req.Header.Add("Authorization", "Bearer node."+c.Token)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(c.Status, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+			assert.Contains(w.Body.String(), service.VmessUser.UUID)
+
+			// Polling with the same ETag gives 304
+			etag := w.Header().Get("ETag")
+			assert.NotEmpty(etag)
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", "/v1/nodes/"+strconv.FormatUint(node.ID, 10)+"/config?format="+c.Format, nil)
+			req.Header.Add("Authorization", "Bearer node."+c.Token)
+			req.Header.Add("If-None-Match", etag)
+			router.ServeHTTP(w, req)
+			assert.Equal(http.StatusNotModified, w.Code)
+			assert.Empty(w.Body.String())
+		})
+	}
+}
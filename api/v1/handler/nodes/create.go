@@ -2,7 +2,6 @@ package nodes
 
 import (
 	"net/http"
-	"strconv"
 
 	"github.com/coolray-dev/raydash/modules/casbin"
 	"github.com/coolray-dev/raydash/modules/utils"
@@ -43,6 +42,12 @@ func Create(c *gin.Context) {
 		return
 	}
 
+	if err := node.Validate(); err != nil {
+		log.Log.WithError(err).Warn("Invalid Node")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
 	// Generate access token
 	node.AccessToken = utils.RandString(64)
 
@@ -56,9 +61,7 @@ func Create(c *gin.Context) {
 	}
 
 	// Deal with casbin
-	casbin.Enforcer.AddPolicy("node::"+strconv.Itoa(int(node.ID)),
-		"/*/nodes/"+strconv.Itoa(int(node.ID))+"*",
-		"*")
+	casbin.AddNodePolicy(&node)
 
 	// Return result
 	log.Log.Debug("Success")
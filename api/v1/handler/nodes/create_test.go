@@ -0,0 +1,65 @@
+package nodes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/gin-gonic/gin"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestCreateMultiplier(t *testing.T) {
+	router := testutils.GetRouter()
+	adminToken := testutils.SignAccessToken(&admin)
+
+	cases := []struct {
+		Name       string
+		Multiplier interface{} // nil leaves it out of the request
+		Status     int
+		Want       float64
+	}{
+		{"Absent", nil, http.StatusOK, 1},
+		{"Free node", 0, http.StatusOK, 0},
+		{"Premium node", 2.5, http.StatusOK, 2.5},
+		{"Negative", -1, http.StatusBadRequest, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			body := gin.H{"name": gofakeit.Word(), "host": gofakeit.DomainName()}
+			if c.Multiplier != nil {
+				body["multiplier"] = c.Multiplier
+			}
+			b, _ := json.Marshal(body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/nodes", bytes.NewReader(b))
+			req.Header.Add("Authorization", "Bearer "+adminToken)
+			router.ServeHTTP(w, req)
+			assert.Equal(c.Status, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				Node models.Node `json:"node"`
+			}
+			assert.Nil(json.Unmarshal(w.Body.Bytes(), &res))
+			assert.NotZero(res.Node.ID)
+			var node models.Node
+			assert.Nil(orm.DB.First(&node, res.Node.ID).Error)
+			defer orm.DB.Delete(&node)
+			if assert.NotNil(node.Multiplier) {
+				assert.Equal(c.Want, *node.Multiplier)
+			}
+		})
+	}
+}
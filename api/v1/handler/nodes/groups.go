@@ -0,0 +1,215 @@
+package nodes
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/provision"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type groupsResponse struct {
+	Groups []*models.Group `json:"groups"`
+}
+
+type groupsRequest struct {
+	GIDs []uint64 `json:"gids" binding:"required"`
+}
+
+// Groups return all groups a node is assigned to
+//
+// Groups godoc
+// @Summary Get Node Groups
+// @Description List out all groups a node is assigned to
+// @ID Nodes.Groups
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Accept  json
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} groupsResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/groups [get]
+func Groups(c *gin.Context) {
+	node, ok := findNode(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, groupsResponse{
+		Groups: node.Groups,
+	})
+	return
+}
+
+// SetGroups replace groups of a node, members of added groups get services on
+// the node and members of removed groups lose them if no other group gives the node
+//
+// SetGroups godoc
+// @Summary Set Node Groups
+// @Description Replace groups a node is assigned to
+// @ID Nodes.SetGroups
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Accept  json
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param groups body groupsRequest true "Group IDs"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} groupsResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/groups [put]
+func SetGroups(c *gin.Context) {
+	changeGroups(c, true)
+}
+
+// AppendGroups assign a node to groups, members get services on the node
+//
+// AppendGroups godoc
+// @Summary Append Node Groups
+// @Description Assign a node to groups
+// @ID Nodes.AppendGroups
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Accept  json
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param groups body groupsRequest true "Group IDs"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} groupsResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/groups [patch]
+func AppendGroups(c *gin.Context) {
+	changeGroups(c, false)
+}
+
+// RemoveGroup unassign a node from a group, members lose services on the node
+// if no other group gives it
+//
+// RemoveGroup godoc
+// @Summary Remove Node Group
+// @Description Unassign a node from a group
+// @ID Nodes.RemoveGroup
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Accept  json
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param gid path uint true "Group ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} groupsResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/groups/{gid} [delete]
+func RemoveGroup(c *gin.Context) {
+	gid, err := strconv.ParseUint(c.Param("gid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid GID: " + err.Error()})
+		return
+	}
+	node, ok := findNode(c)
+	if !ok {
+		return
+	}
+
+	for _, g := range node.Groups {
+		if g.ID == gid {
+			applyGroups(c, node, nil, provision.NodeAssignments(node, []*models.Group{g}))
+			return
+		}
+	}
+	c.JSON(http.StatusNotFound, gin.H{"error": "Node not in group"})
+}
+
+// changeGroups adds node to groups in request, groups not in request are removed if replace
+func changeGroups(c *gin.Context, replace bool) {
+	var json groupsRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		log.Log.WithError(err).Warn("Request Binding Error")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	node, ok := findNode(c)
+	if !ok {
+		return
+	}
+
+	groups, err := provision.FindGroups(orm.DB, json.GIDs)
+	if errors.Is(err, provision.ErrNotFound) {
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	add, remove := provision.Diff(
+		provision.NodeAssignments(node, node.Groups),
+		provision.NodeAssignments(node, groups),
+		replace)
+	applyGroups(c, node, add, remove)
+}
+
+// applyGroups changes groups of node and responds with the groups after it
+func applyGroups(c *gin.Context, node *models.Node, add, remove []provision.Assignment) {
+	err := provision.SetAssignments(orm.DB, add, remove)
+	if errors.Is(err, models.ErrPortInUse) || errors.Is(err, models.ErrNoFreePort) {
+		log.Log.WithError(err).WithField("nodeID", node.ID).Warn("Error Provisioning Services")
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var groups []*models.Group
+	if err := orm.DB.Model(node).Association("Groups").Find(&groups); err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, groupsResponse{
+		Groups: groups,
+	})
+	return
+}
+
+// findNode loads the node in url with its groups,
+// response is written when it fails so caller should simply return
+func findNode(c *gin.Context) (*models.Node, bool) {
+	nid, err := parseNID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Node ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	var node models.Node
+	if err := orm.DB.Preload("Groups").First(&node, nid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("nodeID", nid).Warn("Node Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return nil, false
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	return &node, true
+}
@@ -0,0 +1,108 @@
+package nodes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/gin-gonic/gin"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestGroups(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	group := models.Group{Name: gofakeit.Word(), Users: []*models.User{&user}}
+	orm.DB.Create(&group)
+	casbin.Enforcer.AddGroupingPolicy(user.Username, "group::"+group.Name)
+	defer casbin.Enforcer.RemoveGroupingPolicy(user.Username, "group::"+group.Name)
+
+	nodePath := "/v1/nodes/" + strconv.FormatUint(otherNode.ID, 10)
+	groupPath := "/v1/groups/" + strconv.FormatUint(group.ID, 10)
+	request := func(method, path, token string, body gin.H) *httptest.ResponseRecorder {
+		b, _ := json.Marshal(body)
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(method, path, bytes.NewReader(b))
+		req.Header.Add("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		return w
+	}
+	services := func() int64 {
+		var count int64
+		orm.DB.Model(&models.Service{}).Where("node_id = ? AND user_id = ?", otherNode.ID, user.ID).Count(&count)
+		return count
+	}
+	adminToken := testutils.SignAccessToken(&admin)
+	userToken := testutils.SignAccessToken(&user)
+
+	// Members could not see the node before it is in their group
+	assert.Equal(http.StatusForbidden, request("GET", nodePath, userToken, nil).Code)
+
+	w := request("PATCH", nodePath+"/groups", adminToken, gin.H{"gids": []uint64{group.ID}})
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), group.Name)
+	assert.Equal(int64(1), services())
+	assert.Equal(http.StatusOK, request("GET", nodePath, userToken, nil).Code)
+
+	// Same assignment from the group side changes nothing
+	w = request("GET", groupPath+"/nodes", adminToken, nil)
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Body.String(), otherNode.Name)
+	assert.Equal(http.StatusOK, request("PATCH", groupPath+"/nodes", adminToken, gin.H{"nids": []uint64{otherNode.ID}}).Code)
+	assert.Equal(int64(1), services())
+
+	assert.Equal(http.StatusNotFound, request("PUT", groupPath+"/nodes", adminToken, gin.H{"nids": []uint64{otherNode.ID + 1000}}).Code)
+	assert.Equal(http.StatusBadRequest, request("PUT", groupPath+"/nodes", adminToken, gin.H{}).Code)
+
+	// Emptying the group takes services and access away
+	assert.Equal(http.StatusOK, request("PUT", groupPath+"/nodes", adminToken, gin.H{"nids": []uint64{}}).Code)
+	assert.Equal(int64(0), services())
+	assert.Equal(http.StatusForbidden, request("GET", nodePath, userToken, nil).Code)
+
+	// And back through the other side
+	assert.Equal(http.StatusOK, request("PUT", nodePath+"/groups", adminToken, gin.H{"gids": []uint64{group.ID}}).Code)
+	assert.Equal(int64(1), services())
+	assert.Equal(http.StatusOK, request("DELETE", nodePath+"/groups/"+strconv.FormatUint(group.ID, 10), adminToken, nil).Code)
+	assert.Equal(int64(0), services())
+	assert.Equal(http.StatusNotFound, request("DELETE", groupPath+"/nodes/"+strconv.FormatUint(otherNode.ID, 10), adminToken, nil).Code)
+}
+
+func TestNodeTokenScope(t *testing.T) {
+	router := testutils.GetRouter()
+
+	nodePath := "/v1/nodes/" + strconv.FormatUint(node.ID, 10)
+	cases := []struct {
+		Method string
+		Path   string
+		Status int
+	}{
+		{"GET", nodePath + "/config", http.StatusOK},
+		{"GET", nodePath + "/users", http.StatusOK},
+		{"GET", nodePath + "/groups", http.StatusForbidden},
+		{"PUT", nodePath + "/groups", http.StatusForbidden},
+		{"PATCH", nodePath + "/groups", http.StatusForbidden},
+		{"DELETE", nodePath + "/groups/1", http.StatusForbidden},
+		{"PUT", nodePath + "/state", http.StatusForbidden},
+		{"PATCH", nodePath, http.StatusForbidden},
+		{"POST", nodePath + "/token", http.StatusForbidden},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Method+" "+c.Path, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest(c.Method, c.Path, bytes.NewReader([]byte("{}")))
+			req.Header.Add("Authorization", "Bearer node."+node.AccessToken)
+			router.ServeHTTP(w, req)
+			assertlib.Equal(t, c.Status, w.Code)
+		})
+	}
+}
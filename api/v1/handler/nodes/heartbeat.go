@@ -0,0 +1,106 @@
+package nodes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type heartbeatRequest struct {
+	Uptime       uint64    `json:"uptime"`               // seconds
+	Load         []float64 `json:"load" binding:"max=3"` // 1, 5 and 15 minutes load average
+	OnlineUsers  uint      `json:"onlineUsers"`          // users with traffic since last heartbeat
+	AgentVersion string    `json:"agentVersion" binding:"max=64"`
+}
+
+type heartbeatResponse struct {
+	Node models.Node `json:"node"`
+}
+
+// Heartbeat receive status of a node from its agent and mark the node as seen
+//
+// Heartbeat godoc
+// @Summary Node heartbeat
+// @Description Record uptime, load, online users and agent version of a node and mark it online
+// @ID Nodes.Heartbeat
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Accept  json
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param heartbeat body heartbeatRequest true "Node Status"
+// @Param Authorization header string true "Node Token"
+// @Success 200 {object} heartbeatResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/heartbeat [post]
+func Heartbeat(c *gin.Context) {
+
+	// Get Node ID
+	nid, err := parseNID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Node ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var json heartbeatRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		log.Log.WithError(err).Warn("Request Binding Error")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var node models.Node
+	if err := orm.DB.First(&node, nid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("nodeID", nid).Warn("Node Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	now := time.Now()
+	heartbeat := models.Heartbeat{
+		LastSeenAt:   &now,
+		Uptime:       json.Uptime,
+		OnlineUsers:  json.OnlineUsers,
+		AgentVersion: json.AgentVersion,
+	}
+	loads := []*float64{&heartbeat.Load1, &heartbeat.Load5, &heartbeat.Load15}
+	for i, l := range json.Load {
+		*loads[i] = l
+	}
+
+	// UpdateColumns skips hooks and updated_at, a heartbeat is not an edit of the node
+	if err := orm.DB.Model(&node).UpdateColumns(map[string]interface{}{
+		"last_seen_at":  heartbeat.LastSeenAt,
+		"uptime":        heartbeat.Uptime,
+		"load1":         heartbeat.Load1,
+		"load5":         heartbeat.Load5,
+		"load15":        heartbeat.Load15,
+		"online_users":  heartbeat.OnlineUsers,
+		"agent_version": heartbeat.AgentVersion,
+	}).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	node.Heartbeat = heartbeat
+	node.Online = true
+
+	c.JSON(http.StatusOK, heartbeatResponse{
+		Node: node,
+	})
+	return
+}
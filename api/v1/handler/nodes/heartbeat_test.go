@@ -0,0 +1,71 @@
+package nodes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/gin-gonic/gin"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestHeartbeat(t *testing.T) {
+	router := testutils.GetRouter()
+
+	show := func(assert *assertlib.Assertions) models.Node {
+		var body struct {
+			Node models.Node `json:"node"`
+		}
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/nodes/"+strconv.FormatUint(otherNode.ID, 10), nil)
+		req.Header.Add("Authorization", "Bearer "+testutils.SignAccessToken(&admin))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Nil(json.Unmarshal(w.Body.Bytes(), &body))
+		return body.Node
+	}
+
+	cases := []struct {
+		Name   string
+		Token  string
+		Body   gin.H
+		Status int
+	}{
+		{"Other node", node.AccessToken, gin.H{"uptime": 1}, http.StatusForbidden},
+		{"Too many loads", otherNode.AccessToken, gin.H{"load": []float64{1, 2, 3, 4}}, http.StatusBadRequest},
+		{"Heartbeat", otherNode.AccessToken, gin.H{
+			"uptime":       3600,
+			"load":         []float64{0.5, 0.25, 0.125},
+			"onlineUsers":  3,
+			"agentVersion": "v0.1.0",
+		}, http.StatusOK},
+	}
+
+	assert := assertlib.New(t)
+	assert.False(show(assert).Online)
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			body, _ := json.Marshal(c.Body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/nodes/"+strconv.FormatUint(otherNode.ID, 10)+"/heartbeat", bytes.NewReader(body))
+			req.Header.Add("Authorization", "Bearer node."+c.Token)
+			router.ServeHTTP(w, req)
+			assert.Equal(c.Status, w.Code)
+		})
+	}
+
+	n := show(assert)
+	assert.True(n.Online)
+	assert.Equal(uint64(3600), n.Uptime)
+	assert.Equal(0.25, n.Load5)
+	assert.Equal(uint(3), n.OnlineUsers)
+	assert.Equal("v0.1.0", n.AgentVersion)
+}
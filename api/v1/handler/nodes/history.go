@@ -0,0 +1,78 @@
+package nodes
+
+import (
+	"errors"
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type historyResponse struct {
+	Interval string                `json:"interval"`
+	History  []models.TrafficPoint `json:"history"`
+}
+
+// History returns traffic of a node aggregated by hour or day
+//
+// History godoc
+// @Summary Node traffic history
+// @Description Traffic of a node between from and to by interval, optionally of a single user
+// @ID Nodes.History
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param from query int false "Unix timestamp, defaults to a day or 30 days before to"
+// @Param to query int false "Unix timestamp, defaults to now"
+// @Param interval query string false "hour (default) or day"
+// @Param uid query uint false "User ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} historyResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/traffic/history [get]
+func History(c *gin.Context) {
+
+	// Get Node ID
+	nid, err := parseNID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Node ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var node models.Node
+	if err := orm.DB.First(&node, nid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("nodeID", nid).Warn("Node Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := orm.DB.Where("node_id = ?", nid)
+	if uid, ok := c.Get("uid"); ok {
+		query = query.Where("user_id = ?", uid)
+	}
+	interval := c.GetString("interval")
+	history, err := models.TrafficHistory(query, c.GetTime("from"), c.GetTime("to"), interval)
+	if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historyResponse{
+		Interval: interval,
+		History:  history,
+	})
+	return
+}
@@ -2,6 +2,7 @@ package nodes
 
 import (
 	"net/http"
+	"time"
 
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
@@ -14,8 +15,8 @@ type indexResponse struct {
 	Nodes []models.Node `json:"nodes"`
 }
 
-// Index handle GET /nodes which simply list out all nodes
-// accept gid param as filter
+// Index handle GET /nodes which simply list out all nodes ordered by sort
+// accept gid and tags params as filter, only admins see unavailable nodes
 //
 // Index godoc
 // @Summary All Nodes
@@ -25,6 +26,8 @@ type indexResponse struct {
 // @Tags Nodes
 // @Accept  json
 // @Produce  json
+// @Param gid query uint false "Group ID"
+// @Param tags query string false "Comma separated tags, nodes having any of them are listed"
 // @Param Authorization header string true "Access Token"
 // @Success 200 {object} indexResponse
 // @Failure 403 {object} handler.ErrorResponse
@@ -33,11 +36,22 @@ type indexResponse struct {
 func Index(c *gin.Context) {
 	var n []models.Node
 	nodes := &n
-	if err := orm.DB.Preload("Groups").Find(nodes).Order("updated_at desc").Error; err != nil {
+	if err := orm.DB.Preload("Groups").Order("sort, id").Find(nodes).Error; err != nil {
 		log.Log.WithError(err).Error("Database Error")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
 	}
+	// Nodes in maintenance or disabled are hidden from users
+	if !c.GetBool("isAdmin") {
+		now := time.Now()
+		var t []models.Node
+		for i := range *nodes {
+			if (*nodes)[i].Available(now) {
+				t = append(t, (*nodes)[i])
+			}
+		}
+		nodes = &t
+	}
 	if gid, exists := c.Get("gid"); exists {
 		var t []models.Node
 		for _, n := range *nodes {
@@ -50,6 +64,15 @@ func Index(c *gin.Context) {
 		}
 		nodes = &t
 	}
+	if tags := models.ParseTags(c.Query("tags")); len(tags) > 0 {
+		var t []models.Node
+		for i := range *nodes {
+			if (*nodes)[i].HasAnyTag(tags) {
+				t = append(t, (*nodes)[i])
+			}
+		}
+		nodes = &t
+	}
 
 	c.JSON(http.StatusOK, indexResponse{
 		Total: uint(len(*nodes)),
@@ -0,0 +1,35 @@
+package nodes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestIndexServerKey(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	ss := models.Node{Name: gofakeit.Word()}
+	ss.Protocol = models.ProtocolShadowsocks
+	ss.Method = "2022-blake3-aes-128-gcm"
+	orm.DB.Create(&ss)
+	assert.NotEmpty(ss.ServerKey)
+
+	// The server key of shadowsocks 2022 nodes is never listed
+	for _, u := range []*models.User{&user, &admin} {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/nodes", nil)
+		req.Header.Add("Authorization", "Bearer "+testutils.SignAccessToken(u))
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+		assert.Contains(w.Body.String(), ss.Name)
+		assert.NotContains(w.Body.String(), ss.ServerKey)
+	}
+}
@@ -0,0 +1,60 @@
+package nodes_test
+
+import (
+	"os"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/coolray-dev/raydash/modules/utils"
+)
+
+// Create two nodes and a user owning a service on the first one
+var node, otherNode models.Node
+var user, admin models.User
+var service models.Service
+
+func TestMain(m *testing.M) {
+
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	node = models.Node{
+		Name:        gofakeit.Word(),
+		Host:        gofakeit.DomainName(),
+		AccessToken: utils.RandString(64),
+	}
+	node.Port = 443
+	orm.DB.Create(&node)
+	casbin.AddNodePolicy(&node)
+
+	otherNode = models.Node{
+		Name:        gofakeit.Word(),
+		AccessToken: utils.RandString(64),
+	}
+	orm.DB.Create(&otherNode)
+	casbin.AddNodePolicy(&otherNode)
+
+	gofakeit.Struct(&user)
+	orm.DB.Create(&user)
+	casbin.AddDefaultUserPolicy(&user)
+
+	gofakeit.Struct(&admin)
+	orm.DB.Create(&admin)
+	casbin.Enforcer.AddGroupingPolicy(admin.Username, "group::admin")
+
+	service = models.Service{
+		Name:   gofakeit.Word(),
+		UserID: user.ID,
+		NodeID: node.ID,
+	}
+	service.VmessUser.UUID = gofakeit.UUID()
+	orm.DB.Create(&service)
+
+	code := m.Run()
+	casbin.Enforcer.RemoveGroupingPolicy(admin.Username, "group::admin")
+	os.Exit(code)
+}
@@ -16,7 +16,7 @@ type servicesResponse struct {
 	Services []*models.Service `json:"services"`
 }
 
-// Services receive a id from request url and return all the services a node has
+// Services receive a id from request url and return the services of active users on a node
 //
 // Services godoc
 // @Summary Node Services
@@ -43,7 +43,7 @@ func Services(c *gin.Context) {
 
 	var node models.Node
 
-	if err := orm.DB.Preload("Services").Where("id = ?", nid).First(&node).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+	if err := orm.DB.Preload("Services", models.ActiveServices).Where("id = ?", nid).First(&node).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		log.Log.WithField("nodeID", nid).Warn("Node Not Found")
 		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
 		return
@@ -0,0 +1,114 @@
+package nodes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/maintenance"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type stateRequest struct {
+	State   string     `json:"state" binding:"required,oneof=active maintenance disabled"`
+	Start   *time.Time `json:"start"` // optional maintenance window
+	End     *time.Time `json:"end"`
+	Message string     `json:"message"`
+}
+
+type stateResponse struct {
+	Node models.Node `json:"node"`
+}
+
+// State changes state and maintenance window of a node. Users having services
+// on the node get an announcement and a mail when it enters or leaves maintenance,
+// MaintenanceEndJob does the same for a window that ends by itself.
+//
+// State godoc
+// @Summary Set Node State
+// @Description Set node active, in maintenance or disabled, nodes not active are hidden from users but keep services
+// @ID Nodes.State
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Accept  json
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param state body stateRequest true "Node State"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} stateResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/state [put]
+func State(c *gin.Context) {
+
+	// Get Node ID
+	nid, err := parseNID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Node ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var json stateRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		log.Log.WithError(err).Warn("Request Binding Error")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var node models.Node
+	if err := orm.DB.First(&node, nid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("nodeID", nid).Warn("Node Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	wasMaintained := underMaintenance(&node)
+	node.State = json.State
+	node.Maintenance = models.Maintenance{
+		MaintenanceStart:   json.Start,
+		MaintenanceEnd:     json.End,
+		MaintenanceMessage: json.Message,
+	}
+	if err := node.Validate(); err != nil {
+		log.Log.WithError(err).Warn("Invalid Node")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Select so nil window and empty message are written too
+	if err := orm.DB.Model(&node).
+		Select("state", "maintenance_start", "maintenance_end", "maintenance_message").
+		Updates(&node).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch maintained := underMaintenance(&node); {
+	case !wasMaintained && maintained:
+		maintenance.Notify(&node, true)
+	case wasMaintained && !maintained && node.State == models.NodeActive:
+		maintenance.Notify(&node, false)
+	}
+
+	c.JSON(http.StatusOK, stateResponse{
+		Node: node,
+	})
+	return
+}
+
+// underMaintenance tells whether the node is or will be in maintenance
+func underMaintenance(n *models.Node) bool {
+	return n.State == models.NodeMaintenance || n.MaintenanceStart != nil || n.MaintenanceEnd != nil
+}
@@ -0,0 +1,106 @@
+package nodes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/mail"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/gin-gonic/gin"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestState(t *testing.T) {
+	router := testutils.GetRouter()
+	adminToken := testutils.SignAccessToken(&admin)
+
+	// Catch mails instead of sending them
+	mail.MailChan = make(chan *models.Mail, 5)
+	defer func() { mail.MailChan = nil }()
+
+	start := time.Now().Add(-time.Hour)
+	end := time.Now().Add(time.Hour)
+	cases := []struct {
+		Name     string
+		Body     gin.H
+		Status   int
+		State    string
+		Notified string // level of the announcement posted, empty means none
+	}{
+		{"Invalid state", gin.H{"state": "broken"}, http.StatusBadRequest, models.NodeActive, ""},
+		{"Invalid window", gin.H{"state": "maintenance", "start": end, "end": start}, http.StatusBadRequest, models.NodeActive, ""},
+		{"Enter maintenance", gin.H{"state": "maintenance", "start": start, "end": end, "message": "Upgrading kernel"}, http.StatusOK, models.NodeMaintenance, "warning"},
+		{"Still in maintenance", gin.H{"state": "maintenance", "message": "Taking longer"}, http.StatusOK, models.NodeMaintenance, ""},
+		{"Leave maintenance", gin.H{"state": "active"}, http.StatusOK, models.NodeActive, "info"},
+		{"Disable", gin.H{"state": "disabled"}, http.StatusOK, models.NodeDisabled, ""},
+		{"Enable", gin.H{"state": "active"}, http.StatusOK, models.NodeActive, ""},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			var before int64
+			orm.DB.Model(&models.Announcement{}).Count(&before)
+
+			body, _ := json.Marshal(c.Body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PUT", "/v1/nodes/"+strconv.FormatUint(node.ID, 10)+"/state", bytes.NewReader(body))
+			req.Header.Add("Authorization", "Bearer "+adminToken)
+			router.ServeHTTP(w, req)
+			assert.Equal(c.Status, w.Code)
+
+			var n models.Node
+			orm.DB.First(&n, node.ID)
+			assert.Equal(c.State, n.State)
+
+			var after int64
+			orm.DB.Model(&models.Announcement{}).Count(&after)
+			if c.Notified == "" {
+				assert.Equal(before, after)
+				assert.Len(mail.MailChan, 0)
+				return
+			}
+			assert.Equal(before+1, after)
+			var ann models.Announcement
+			orm.DB.Last(&ann)
+			assert.Equal(c.Notified, ann.Level)
+			assert.Contains(ann.Title, node.Name)
+
+			// The user owning a service on the node is mailed, mails are queued in background
+			select {
+			case m := <-mail.MailChan:
+				assert.Equal(user.Email, m.To)
+			case <-time.After(time.Second):
+				t.Fatal("Mail not queued")
+			}
+		})
+	}
+}
+
+func TestIndexUnavailable(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	disabled := models.Node{Name: gofakeit.Word(), State: models.NodeDisabled}
+	orm.DB.Create(&disabled)
+
+	list := func(token string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/nodes", nil)
+		req.Header.Add("Authorization", "Bearer "+token)
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+	assert.NotContains(list(testutils.SignAccessToken(&user)), `"id":`+strconv.FormatUint(disabled.ID, 10)+`,`)
+	assert.Contains(list(testutils.SignAccessToken(&admin)), `"id":`+strconv.FormatUint(disabled.ID, 10)+`,`)
+}
@@ -0,0 +1,179 @@
+package nodes
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type serviceTraffic struct {
+	SID      uint64 `json:"sid" binding:"required"`
+	Upload   uint64 `json:"upload"`
+	Download uint64 `json:"download"`
+}
+
+type reportRequest struct {
+	Traffic []serviceTraffic `json:"traffic" binding:"required,dive"`
+}
+
+type reportResponse struct {
+	Report  models.TrafficReport `json:"report"`
+	Unknown []uint64             `json:"unknown"` // services not on this node, their traffic is dropped
+}
+
+// Report receive traffic deltas of services on a node since last report and
+// add them to users and node in one transaction. Services no longer on the
+// node, usually removed since the agent last synced, are skipped and
+// returned so the agent could drop them without losing the rest.
+//
+// Report godoc
+// @Summary Report node traffic
+// @Description Add a batch of per-service upload/download deltas to users and node atomically, skipping and returning unknown services
+// @ID Nodes.Report
+// @Security ApiKeyAuth
+// @Tags Nodes
+// @Accept  json
+// @Produce  json
+// @Param nid path uint true "Node ID"
+// @Param traffic body reportRequest true "Traffic Deltas"
+// @Param Authorization header string true "Node Token"
+// @Success 201 {object} reportResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /nodes/{nid}/traffic [post]
+func Report(c *gin.Context) {
+
+	// Get Node ID
+	nid, err := parseNID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Node ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var json reportRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		log.Log.WithError(err).Warn("Request Binding Error")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var node models.Node
+	if err := orm.DB.First(&node, nid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("nodeID", nid).Warn("Node Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	report := models.TrafficReport{NodeID: nid}
+	var unknown []uint64
+	err = orm.DB.Transaction(func(tx *gorm.DB) (err error) {
+		unknown, err = applyTraffic(tx, &node, &report, json.Traffic)
+		return err
+	})
+	if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(unknown) > 0 {
+		log.Log.WithField("nodeID", nid).WithField("services", unknown).Warn("Traffic Of Unknown Services Dropped")
+	}
+
+	c.JSON(http.StatusCreated, reportResponse{
+		Report:  report,
+		Unknown: unknown,
+	})
+	return
+}
+
+// applyTraffic increments counters in database instead of overwriting them,
+// so reports from different nodes never clobber each other. Users are charged
+// with the node multiplier applied and suspended when running out of quota.
+// It returns services of the batch which are not on the node.
+func applyTraffic(tx *gorm.DB, node *models.Node, report *models.TrafficReport, traffic []serviceTraffic) ([]uint64, error) {
+
+	// A service could appear several times in one batch
+	deltas := make(map[uint64]*serviceTraffic)
+	var sids []uint64
+	for i := range traffic {
+		t := &traffic[i]
+		if d, ok := deltas[t.SID]; ok {
+			d.Upload += t.Upload
+			d.Download += t.Download
+			continue
+		}
+		deltas[t.SID] = &serviceTraffic{SID: t.SID, Upload: t.Upload, Download: t.Download}
+		sids = append(sids, t.SID)
+	}
+
+	var services []models.Service
+	if len(sids) > 0 {
+		if err := tx.Where("node_id = ? AND id IN ?", report.NodeID, sids).Find(&services).Error; err != nil {
+			return nil, err
+		}
+	}
+	unknown := []uint64{}
+	if len(services) != len(sids) {
+		found := make(map[uint64]bool, len(services))
+		for _, s := range services {
+			found[s.ID] = true
+		}
+		for _, sid := range sids {
+			if !found[sid] {
+				unknown = append(unknown, sid)
+			}
+		}
+	}
+
+	now := time.Now()
+	users := make(map[uint64]uint64)
+	for _, s := range services {
+		d := deltas[s.ID]
+		users[s.UserID] += d.Upload + d.Download
+		report.Upload += d.Upload
+		report.Download += d.Download
+		if d.Upload+d.Download == 0 {
+			continue
+		}
+		if err := models.AddTrafficLog(tx, s.UserID, s.ID, report.NodeID, now, d.Upload, d.Download); err != nil {
+			return nil, err
+		}
+	}
+	report.Services = uint(len(services))
+
+	var uids []uint64
+	for uid, total := range users {
+		charge := node.Charge(total)
+		if charge == 0 {
+			continue
+		}
+		if err := tx.Model(&models.User{}).Where("id = ?", uid).
+			UpdateColumn("current_traffic", gorm.Expr("current_traffic + ?", int64(charge))).Error; err != nil {
+			return nil, err
+		}
+		uids = append(uids, uid)
+	}
+	if err := models.EnforceUsers(tx, uids); err != nil {
+		return nil, err
+	}
+	if total := report.Upload + report.Download; total > 0 {
+		if err := tx.Model(&models.Node{}).Where("id = ?", report.NodeID).
+			UpdateColumn("current_traffic", gorm.Expr("current_traffic + ?", total)).Error; err != nil {
+			return nil, err
+		}
+	}
+	return unknown, tx.Create(report).Error
+}
@@ -0,0 +1,258 @@
+package nodes_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/coolray-dev/raydash/modules/utils"
+	"github.com/gin-gonic/gin"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestReport(t *testing.T) {
+	router := testutils.GetRouter()
+
+	type traffic struct {
+		SID      uint64 `json:"sid"`
+		Upload   uint64 `json:"upload"`
+		Download uint64 `json:"download"`
+	}
+
+	cases := []struct {
+		Name    string
+		Token   string
+		Traffic []traffic
+		Status  int
+		Delta   int64
+	}{
+		{"Single service", node.AccessToken, []traffic{{service.ID, 100, 200}}, http.StatusCreated, 300},
+		{"Repeated service", node.AccessToken, []traffic{{service.ID, 1, 2}, {service.ID, 3, 4}}, http.StatusCreated, 10},
+		{"Unknown service skipped", node.AccessToken, []traffic{{service.ID, 1, 1}, {service.ID + 1000, 1, 1}}, http.StatusCreated, 2},
+		{"Other node", otherNode.AccessToken, []traffic{{service.ID, 1, 1}}, http.StatusForbidden, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			var before models.User
+			orm.DB.First(&before, user.ID)
+			var beforeNode models.Node
+			orm.DB.First(&beforeNode, node.ID)
+
+			body, _ := json.Marshal(gin.H{"traffic": c.Traffic})
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("POST", "/v1/nodes/"+strconv.FormatUint(node.ID, 10)+"/traffic", bytes.NewReader(body))
+			req.Header.Add("Authorization", "Bearer node."+c.Token)
+			router.ServeHTTP(w, req)
+			assert.Equal(c.Status, w.Code)
+			if w.Code == http.StatusCreated {
+				var res struct {
+					Unknown []uint64 `json:"unknown"`
+				}
+				json.Unmarshal(w.Body.Bytes(), &res)
+				var unknown []uint64
+				for _, t := range c.Traffic {
+					if t.SID != service.ID {
+						unknown = append(unknown, t.SID)
+					}
+				}
+				assert.ElementsMatch(unknown, res.Unknown)
+			}
+
+			var after models.User
+			orm.DB.First(&after, user.ID)
+			assert.Equal(before.CurrentTraffic+c.Delta, after.CurrentTraffic)
+			var afterNode models.Node
+			orm.DB.First(&afterNode, node.ID)
+			assert.Equal(beforeNode.CurrentTraffic+uint64(c.Delta), afterNode.CurrentTraffic)
+		})
+	}
+}
+
+func TestHistory(t *testing.T) {
+	router := testutils.GetRouter()
+
+	adminToken := testutils.SignAccessToken(&admin)
+
+	// Traffic reported in TestReport is logged in the current hour
+	now := time.Now().Unix()
+	nodePath := "/v1/nodes/" + strconv.FormatUint(node.ID, 10) + "/traffic/history"
+	userPath := "/v1/users/" + user.Username + "/traffic/history"
+	cases := []struct {
+		Name   string
+		Path   string
+		Token  string
+		Status int
+		Points int
+	}{
+		{"Node hourly", nodePath, adminToken, http.StatusOK, 1},
+		{"Node daily", nodePath + "?interval=day", adminToken, http.StatusOK, 1},
+		{"Node in the past", nodePath + "?to=" + strconv.FormatInt(now-7200, 10), adminToken, http.StatusOK, 0},
+		{"Invalid interval", nodePath + "?interval=week", adminToken, http.StatusBadRequest, 0},
+		{"Invalid range", nodePath + "?from=" + strconv.FormatInt(now, 10) + "&to=" + strconv.FormatInt(now-1, 10), adminToken, http.StatusBadRequest, 0},
+		{"User", userPath, testutils.SignAccessToken(&user), http.StatusOK, 1},
+		{"User on other node", userPath + "?nid=" + strconv.FormatUint(otherNode.ID, 10), testutils.SignAccessToken(&user), http.StatusOK, 0},
+		{"Node by itself", nodePath, "node." + node.AccessToken, http.StatusForbidden, 0},
+		{"User by node", userPath, "node." + node.AccessToken, http.StatusForbidden, 0},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", c.Path, nil)
+			req.Header.Add("Authorization", "Bearer "+c.Token)
+			router.ServeHTTP(w, req)
+			assert.Equal(c.Status, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			var res struct {
+				History []models.TrafficPoint `json:"history"`
+			}
+			assert.Nil(json.Unmarshal(w.Body.Bytes(), &res))
+			assert.Len(res.History, c.Points)
+			if c.Points > 0 {
+				assert.Equal(uint64(105), res.History[0].Upload)
+				assert.Equal(uint64(207), res.History[0].Download)
+			}
+		})
+	}
+}
+
+func TestSuspend(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	// Both the config and the service list of the node leave out suspended users
+	get := func(what string) string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/nodes/"+strconv.FormatUint(node.ID, 10)+"/"+what, nil)
+		req.Header.Add("Authorization", "Bearer node."+node.AccessToken)
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// Give the user quota of 10 bytes
+	var u models.User
+	orm.DB.First(&u, user.ID)
+	orm.DB.Model(&u).UpdateColumns(map[string]interface{}{"current_traffic": 0, "max_traffic": 10})
+
+	body, _ := json.Marshal(gin.H{"traffic": []gin.H{{"sid": service.ID, "upload": 5, "download": 5}}})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/nodes/"+strconv.FormatUint(node.ID, 10)+"/traffic", bytes.NewReader(body))
+	req.Header.Add("Authorization", "Bearer node."+node.AccessToken)
+	router.ServeHTTP(w, req)
+	assert.Equal(http.StatusCreated, w.Code)
+
+	orm.DB.First(&u, user.ID)
+	assert.Equal(models.UserSuspended, u.Status)
+	assert.NotContains(get("config"), service.VmessUser.UUID)
+	assert.NotContains(get("services"), service.VmessUser.UUID)
+
+	// Raising quota restores the user
+	u.MaxTraffic += 1000
+	assert.True(u.Enforce(time.Now()))
+	orm.DB.Save(&u)
+	assert.Contains(get("config"), service.VmessUser.UUID)
+	assert.Contains(get("services"), service.VmessUser.UUID)
+}
+
+func TestReportMultiplier(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	// Premium node charges users twice the traffic
+	double := 2.0
+	premium := models.Node{
+		Name:        gofakeit.Word(),
+		AccessToken: utils.RandString(64),
+		Multiplier:  &double,
+	}
+	orm.DB.Create(&premium)
+	defer orm.DB.Delete(&premium)
+	casbin.AddNodePolicy(&premium)
+
+	s := models.Service{
+		Name:   gofakeit.Word(),
+		UserID: user.ID,
+		NodeID: premium.ID,
+	}
+	orm.DB.Create(&s)
+	defer orm.DB.Delete(&s)
+
+	var before models.User
+	orm.DB.First(&before, user.ID)
+
+	body, _ := json.Marshal(gin.H{"traffic": []gin.H{{"sid": s.ID, "upload": 100, "download": 50}}})
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("POST", "/v1/nodes/"+strconv.FormatUint(premium.ID, 10)+"/traffic", bytes.NewReader(body))
+	req.Header.Add("Authorization", "Bearer node."+premium.AccessToken)
+	router.ServeHTTP(w, req)
+	assert.Equal(http.StatusCreated, w.Code)
+
+	var after models.User
+	orm.DB.First(&after, user.ID)
+	assert.Equal(before.CurrentTraffic+300, after.CurrentTraffic)
+
+	// Node itself counts real traffic
+	orm.DB.First(&premium, premium.ID)
+	assert.Equal(uint64(150), premium.CurrentTraffic)
+}
+
+func TestLegacyTraffic(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	patch := func(n models.Node, traffic int64) {
+		body, _ := json.Marshal(gin.H{"current_traffic": traffic})
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("PATCH", "/v1/nodes/"+strconv.FormatUint(n.ID, 10)+"/users/"+user.Username+"/traffic", bytes.NewReader(body))
+		req.Header.Add("Authorization", "Bearer node."+n.AccessToken)
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+	}
+	sum := func() (download, unknown uint64) {
+		var s struct {
+			Download uint64
+			Unknown  uint64
+		}
+		orm.DB.Model(&models.TrafficLog{}).Select("COALESCE(SUM(download), 0) AS download, COALESCE(SUM(unknown), 0) AS unknown").
+			Where("service_id = ?", service.ID).Scan(&s)
+		return s.Download, s.Unknown
+	}
+
+	var u models.User
+	orm.DB.First(&u, user.ID)
+	start := u.CurrentTraffic
+	download, unknown := sum()
+
+	// Growth is logged apart from download since its direction is unknown
+	patch(node, start+70)
+	afterDownload, afterUnknown := sum()
+	assert.Equal(download, afterDownload)
+	assert.Equal(unknown+70, afterUnknown)
+
+	// No log is written for a node the user has no service on,
+	// the counter of the user is still updated
+	patch(otherNode, start+100)
+	var count int64
+	orm.DB.Model(&models.TrafficLog{}).Where("service_id = 0").Count(&count)
+	assert.Equal(int64(0), count)
+	orm.DB.First(&u, user.ID)
+	assert.Equal(start+100, u.CurrentTraffic)
+}
@@ -52,12 +52,20 @@ func Update(c *gin.Context) {
 		return
 	}
 
-	// Bind Request
+	// Bind Request, state is changed through PUT /nodes/:nid/state so users get notified
+	state, maintenance := node.State, node.Maintenance
 	if err = c.ShouldBindJSON(&node); err != nil {
 		log.Log.WithError(err).Warn("Error Binding Request")
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	node.State, node.Maintenance = state, maintenance
+
+	if err = node.Validate(); err != nil {
+		log.Log.WithError(err).Warn("Invalid Node")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
 	// Save to DB
 	if err = orm.DB.Save(&node).Error; err != nil {
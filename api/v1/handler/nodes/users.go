@@ -3,6 +3,7 @@ package nodes
 import (
 	"errors"
 	"net/http"
+	"time"
 
 	"github.com/coolray-dev/raydash/modules/utils"
 
@@ -19,7 +20,7 @@ type usersResponse struct {
 	Users []models.User `json:"users"`
 }
 
-// Users receive a id from request url and return all the Users a node has
+// Users receive a id from request url and return all the Users a node has, suspended users are left out
 //
 // Users godoc
 // @Summary Node Users
@@ -67,7 +68,7 @@ func Users(c *gin.Context) {
 
 	// Get users
 	var users []models.User
-	if err := orm.DB.Find(&users, userList).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+	if err := orm.DB.Scopes(models.ActiveUsers).Find(&users, userList).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
 		log.Log.WithError(err).Error("Database Error")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
 		return
@@ -89,7 +90,8 @@ type trafficRequest struct {
 	MaxTraffic     int64 `json:"max_traffic"`
 }
 
-// Traffic receive traffic info and update it
+// Traffic receive traffic info and update it, the value overwrites what other nodes reported,
+// POST /nodes/{nid}/traffic should be used instead
 //
 // Traffic godoc
 // @Summary Update user traffic
@@ -107,6 +109,14 @@ type trafficRequest struct {
 // @Failure 500 {object} handler.ErrorResponse
 // @Router /nodes/{nid}/users/{username}/traffic [patch]
 func Traffic(c *gin.Context) {
+	nid, err := parseNID(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{
+			Error: err.Error(),
+		})
+		return
+	}
+
 	var user models.User
 	username := c.Param("username")
 	if err := orm.DB.Where("username = ?", username).First(&user).Error; err != nil {
@@ -124,14 +134,37 @@ func Traffic(c *gin.Context) {
 		})
 		return
 	}
+	// Growth of the absolute value is all we know to put in the traffic log,
+	// it is logged as unknown since direction is not reported
+	var delta int64
 	if json.CurrentTraffic != 0 {
+		delta = json.CurrentTraffic - user.CurrentTraffic
 		user.CurrentTraffic = json.CurrentTraffic
 	}
 	if json.MaxTraffic != 0 {
 		user.MaxTraffic = json.MaxTraffic
 	}
-
-	if err := orm.DB.Save(&user).Error; err != nil {
+	user.Enforce(time.Now())
+
+	err = orm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Save(&user).Error; err != nil {
+			return err
+		}
+		if delta <= 0 {
+			return nil
+		}
+		var service models.Service
+		if err := tx.Where("node_id = ? AND user_id = ?", nid, user.ID).First(&service).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+			// Counter of the user is still updated, there is just no service to log it for
+			log.Log.WithField("nodeID", nid).WithField("username", user.Username).Warn("No Service Of User On Node, Traffic Not Logged")
+			return nil
+		} else if err != nil {
+			return err
+		}
+		// The absolute value does not tell upload from download
+		return models.AddUnknownTrafficLog(tx, user.ID, service.ID, nid, time.Now(), uint64(delta))
+	})
+	if err != nil {
 		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{
 			Error: err.Error(),
 		})
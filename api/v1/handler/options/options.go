@@ -33,7 +33,14 @@ func Update(c *gin.Context) {
 		return
 	}
 
+	// Reuse existing record so an option never has duplicated rows
 	var opt model.Option
+	if err := orm.DB.Where("name = ?", name).FirstOrInit(&opt).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"error": err.Error(),
+		})
+		return
+	}
 	opt.Value = json.Value
 	opt.Name = name
 
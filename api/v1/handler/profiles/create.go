@@ -0,0 +1,51 @@
+package profiles
+
+import (
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+)
+
+// Create receive a profile object from request and store it in DB
+//
+// Create godoc
+// @Summary Create Profile
+// @Description Create a clash profile holding dns servers, proxy groups and rules
+// @ID Profiles.Create
+// @Security ApiKeyAuth
+// @Tags Profiles
+// @Accept  json
+// @Produce  json
+// @Param profile body models.Profile true "Profile Object"
+// @Param Authorization header string true "Access Token"
+// @Success 201 {object} profileResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /profiles [post]
+func Create(c *gin.Context) {
+	var profile models.Profile
+	if err := c.ShouldBindJSON(&profile); err != nil {
+		log.Log.WithError(err).Warn("Could not bind request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := profile.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err := orm.DB.Create(&profile).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, profileResponse{
+		Profile: profile,
+	})
+	return
+}
@@ -0,0 +1,58 @@
+package profiles
+
+import (
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+)
+
+type destroyResponse struct {
+	Profile string `json:"profile"`
+}
+
+// Destroy receive a id from request and delete it from DB,
+// groups using it fall back to the default profile
+//
+// Destroy godoc
+// @Summary Destroy Profile
+// @Description Destroy clash profile according to pid
+// @ID Profiles.Destroy
+// @Security ApiKeyAuth
+// @Tags Profiles
+// @Accept  json
+// @Produce  json
+// @Param pid path uint true "Profile ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} destroyResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /profiles/{pid} [delete]
+func Destroy(c *gin.Context) {
+	pid, err := parsePID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Profile ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	if err = orm.DB.Model(&models.Group{}).Where("profile_id = ?", pid).Update("profile_id", nil).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	var profile models.Profile
+	profile.ID = pid
+	if err = orm.DB.Delete(&profile).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, destroyResponse{
+		Profile: "",
+	})
+	return
+}
@@ -0,0 +1,44 @@
+package profiles
+
+import (
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+)
+
+type indexResponse struct {
+	Total    uint
+	Profiles []models.Profile `json:"profiles"`
+}
+
+// Index handle GET /profiles which simply list out all clash profiles
+//
+// Index godoc
+// @Summary All Profiles
+// @Description Simply list out all clash profiles
+// @ID Profiles.Index
+// @Security ApiKeyAuth
+// @Tags Profiles
+// @Accept  json
+// @Produce  json
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} indexResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /profiles [get]
+func Index(c *gin.Context) {
+	var profiles []models.Profile
+	if err := orm.DB.Order("updated_at desc").Find(&profiles).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, indexResponse{
+		Total:    uint(len(profiles)),
+		Profiles: profiles,
+	})
+}
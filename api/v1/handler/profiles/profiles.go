@@ -0,0 +1,21 @@
+package profiles
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/gin-gonic/gin"
+)
+
+type profileResponse struct {
+	Profile models.Profile `json:"profile"`
+}
+
+func parsePID(c *gin.Context) (pid uint64, err error) {
+	pid, err = strconv.ParseUint(c.Param("pid"), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("Invalid PID: %w", err)
+	}
+	return
+}
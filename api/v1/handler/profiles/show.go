@@ -0,0 +1,54 @@
+package profiles
+
+import (
+	"errors"
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Show receive a id from request url and return the profile of the specific id
+//
+// Show godoc
+// @Summary Show Profile
+// @Description Show clash profile according to pid
+// @ID Profiles.Show
+// @Security ApiKeyAuth
+// @Tags Profiles
+// @Accept  json
+// @Produce  json
+// @Param pid path uint true "Profile ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} profileResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /profiles/{pid} [get]
+func Show(c *gin.Context) {
+	pid, err := parsePID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Profile ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var profile models.Profile
+	if err := orm.DB.First(&profile, pid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("profileID", pid).Warn("Profile Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, profileResponse{
+		Profile: profile,
+	})
+	return
+}
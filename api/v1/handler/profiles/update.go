@@ -0,0 +1,73 @@
+package profiles
+
+import (
+	"errors"
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Update receive a id and a profile object from request and update the specific record in DB
+//
+// Update godoc
+// @Summary Update Profile
+// @Description Update a clash profile
+// @ID Profiles.Update
+// @Security ApiKeyAuth
+// @Tags Profiles
+// @Accept  json
+// @Produce  json
+// @Param pid path uint true "Profile ID"
+// @Param profile body models.Profile true "Profile Object"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} profileResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /profiles/{pid} [patch]
+func Update(c *gin.Context) {
+	pid, err := parsePID(c)
+	if err != nil {
+		log.Log.WithError(err).Warn("Error Getting Profile ID")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var profile models.Profile
+	if err := orm.DB.First(&profile, pid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("profileID", pid).Warn("Profile Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Bind Request
+	if err = c.ShouldBindJSON(&profile); err != nil {
+		log.Log.WithError(err).Warn("Error Binding Request")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err = profile.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// Save to DB
+	if err = orm.DB.Save(&profile).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, profileResponse{
+		Profile: profile,
+	})
+	return
+}
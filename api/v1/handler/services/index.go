@@ -118,15 +118,10 @@ func Index(c *gin.Context) {
 				c.JSON(http.StatusInternalServerError, gin.H{"error": err2.Error()})
 				return
 			}
-			services[i].Protocol = "vmess"
-			services[i].Host = node.Host
-			services[i].Port = node.Settings.Port
-			services[i].VmessSetting = node.Settings.VmessSetting
-			services[i].VmessUser = models.VmessUser{
-				Email:    user.Email,
-				UUID:     user.UUID,
-				AlterID:  64,
-				Security: "auto",
+			if err := node.ApplyTo(&services[i], &user); err != nil {
+				log.Log.WithError(err).Error("Error Applying Node Settings")
+				c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+				return
 			}
 		}
 
@@ -1,7 +1,9 @@
 package services
 
 import (
+	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 
 	"github.com/coolray-dev/raydash/models"
@@ -19,3 +21,15 @@ func parseSID(c *gin.Context) (sid uint64, err error) {
 	}
 	return
 }
+
+// portErrorStatus returns the response status of a port assignment error,
+// 0 means err is not about ports
+func portErrorStatus(err error) int {
+	switch {
+	case errors.Is(err, models.ErrPortOutOfRange):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrPortInUse), errors.Is(err, models.ErrNoFreePort):
+		return http.StatusConflict
+	}
+	return 0
+}
@@ -0,0 +1,82 @@
+package services_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strconv"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/gin-gonic/gin"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+// An admin and a service of the admin on a multi port node
+var admin models.User
+var node models.Node
+var service models.Service
+
+func TestMain(m *testing.M) {
+
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	gofakeit.Struct(&admin)
+	orm.DB.Create(&admin)
+	casbin.Enforcer.AddGroupingPolicy(admin.Username, "group::admin")
+
+	node = models.Node{Name: gofakeit.Word(), Ports: "20000-20010", HasMultiPort: true}
+	orm.DB.Create(&node)
+
+	service = models.Service{Name: gofakeit.Word(), UserID: admin.ID, NodeID: node.ID, Port: 20005}
+	orm.DB.Create(&service)
+
+	code := m.Run()
+	casbin.Enforcer.RemoveGroupingPolicy(admin.Username, "group::admin")
+	os.Exit(code)
+}
+
+func TestUpdate(t *testing.T) {
+	router := testutils.GetRouter()
+	token := testutils.SignAccessToken(&admin)
+	path := "/v1/services/" + strconv.FormatUint(service.ID, 10)
+
+	cases := []struct {
+		Name   string
+		Path   string
+		Body   gin.H
+		Status int
+		Port   uint
+	}{
+		{"Port left out is kept", path, gin.H{"description": "kept"}, http.StatusOK, 20005},
+		{"Port out of range", path, gin.H{"port": 30000}, http.StatusBadRequest, 20005},
+		{"Port changed", path, gin.H{"port": 20008}, http.StatusOK, 20008},
+		{"Port 0 allocates", path, gin.H{"port": 0}, http.StatusOK, 20000},
+		{"Unknown service", "/v1/services/" + strconv.FormatUint(service.ID+1000, 10), gin.H{}, http.StatusNotFound, 20000},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			body, _ := json.Marshal(c.Body)
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("PATCH", c.Path, bytes.NewReader(body))
+			req.Header.Add("Authorization", "Bearer "+token)
+			router.ServeHTTP(w, req)
+			assert.Equal(c.Status, w.Code)
+
+			var s models.Service
+			orm.DB.First(&s, service.ID)
+			assert.Equal(c.Port, s.Port)
+			assert.Equal(service.Name, s.Name)
+		})
+	}
+}
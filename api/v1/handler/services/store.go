@@ -23,9 +23,13 @@ type storeRequest struct {
 	UID         uint64                    `json:"uid" binding:"required"`
 	VS          models.VmessSetting       `json:"vmessSettings"`
 	SS          models.ShadowsocksSetting `json:"shadowsocksSettings"`
+	SU          models.ShadowsocksUser    `json:"shadowsocksUser"`
+	TU          models.TrojanUser         `json:"trojanUser"`
+	VU          models.VlessUser          `json:"vlessUser"`
 }
 
-// Store recieve a service object and store it in DB
+// Store recieve a service object and store it in DB, services on multi port
+// nodes without port get a free one from ports of the node
 //
 // Store godoc
 // @Summary Create Service
@@ -38,7 +42,9 @@ type storeRequest struct {
 // @Param service body storeRequest true "Service Object"
 // @Param Authorization header string true "Access Token"
 // @Success 200 {object} serviceResponse
+// @Failure 400 {object} handler.ErrorResponse
 // @Failure 403 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
 // @Failure 500 {object} handler.ErrorResponse
 // @Router /services [post]
 func Store(c *gin.Context) {
@@ -73,6 +79,18 @@ func Store(c *gin.Context) {
 		service.Protocol = json.Protocol
 		service.VmessSetting = json.VS
 		service.ShadowsocksSetting = json.SS
+		service.ShadowsocksUser = json.SU
+		service.TrojanUser = json.TU
+		service.VlessUser = json.VU
+		if err := service.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if err := service.GenerateCredentials(); err != nil {
+			log.Log.WithError(err).Error("Error Generating Credentials")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
 	} else {
 		var user model.User
 		if err2 := orm.DB.Where("id = ?", json.UID).Find(&user).Error; errors.Is(err2, gorm.ErrRecordNotFound) {
@@ -88,19 +106,29 @@ func Store(c *gin.Context) {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err2.Error()})
 			return
 		}
-		service.Protocol = "vmess"
-		service.Host = node.Host
-		service.Port = node.Settings.Port
-		service.VmessSetting = node.Settings.VmessSetting
-		service.VmessUser = model.VmessUser{
-			Email:    user.Email,
-			UUID:     user.UUID,
-			AlterID:  64,
-			Security: "auto",
+		if err := node.ApplyTo(&service, &user); err != nil {
+			log.Log.WithError(err).Error("Error Applying Node Settings")
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
 		}
 	}
 
-	if err := orm.DB.Create(&service).Error; err != nil {
+	// Port is allocated in the same transaction that holds the node, so
+	// concurrent creates on the node could not take the same port
+	err := orm.DB.Transaction(func(tx *gorm.DB) error {
+		if node.HasMultiPort {
+			if err := models.AssignPort(tx, &node, &service); err != nil {
+				return err
+			}
+		}
+		return tx.Create(&service).Error
+	})
+	if err != nil {
+		if status := portErrorStatus(err); status != 0 {
+			log.Log.WithError(err).WithField("nid", node.ID).Warn("Invalid Service Port")
+			c.JSON(status, gin.H{"error": err.Error()})
+			return
+		}
 		log.Log.WithFields(logrus.Fields{
 			"error": err.Error(),
 		}).Error("Database Error")
@@ -1,6 +1,7 @@
 package services
 
 import (
+	"errors"
 	"net/http"
 
 	orm "github.com/coolray-dev/raydash/database"
@@ -8,9 +9,11 @@ import (
 	"github.com/coolray-dev/raydash/modules/log"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
 )
 
-// Update receive a id and a service object from request and update the specific record in DB
+// Update receive a id and a service object from request and update the specific record in DB,
+// fields not in the request are kept and "port": 0 allocates a new port on multi port nodes
 //
 // Update godoc
 // @Summary Update Service
@@ -24,7 +27,10 @@ import (
 // @Param service body models.Service true "Service Object"
 // @Param Authorization header string true "Access Token"
 // @Success 200 {object} serviceResponse
+// @Failure 400 {object} handler.ErrorResponse
 // @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
 // @Failure 500 {object} handler.ErrorResponse
 // @Router /services/{nid} [patch]
 func Update(c *gin.Context) {
@@ -36,8 +42,18 @@ func Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	// Bind onto the stored service so fields left out of the request are kept
 	var service models.Service
-	service.ID = sid
+	if err := orm.DB.First(&service, sid).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("serviceID", sid).Warn("Service Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	stored := service
 	if err = c.ShouldBindJSON(&service); err != nil {
 		log.Log.WithFields(logrus.Fields{
 			"error": err.Error(),
@@ -45,8 +61,36 @@ func Update(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	service.ID = sid
+	if err = service.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	if err = orm.DB.Save(&service).Error; err != nil {
+	err = orm.DB.Transaction(func(tx *gorm.DB) error {
+		var node models.Node
+		if err := tx.First(&node, service.NodeID).Error; err != nil {
+			return err
+		}
+		// Port is only allocated again when the request sets it to 0,
+		// a changed port or node is checked
+		changed := service.Port != stored.Port || service.NodeID != stored.NodeID
+		if node.HasMultiPort && (service.Port == 0 || changed) {
+			if err := models.AssignPort(tx, &node, &service); err != nil {
+				return err
+			}
+		}
+		return tx.Save(&service).Error
+	})
+	if status := portErrorStatus(err); status != 0 {
+		log.Log.WithError(err).WithField("nid", service.NodeID).Warn("Invalid Service Port")
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("nid", service.NodeID).Warn("No Such Node in Database")
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
 		log.Log.WithFields(logrus.Fields{
 			"error": err.Error(),
 		}).Error("Database Error")
@@ -3,53 +3,195 @@ package subscription
 import (
 	"bytes"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"gorm.io/gorm"
 
 	orm "github.com/coolray-dev/raydash/database"
 	"github.com/coolray-dev/raydash/models"
-	"github.com/coolray-dev/raydash/modules/clash"
 	"github.com/coolray-dev/raydash/modules/log"
+	sub "github.com/coolray-dev/raydash/modules/subscription"
 	"github.com/gin-gonic/gin"
 	"github.com/sirupsen/logrus"
+
+	// Register subscription renderers
+	_ "github.com/coolray-dev/raydash/modules/clash"
+	_ "github.com/coolray-dev/raydash/modules/singbox"
+	_ "github.com/coolray-dev/raydash/modules/v2rayn"
 )
 
-func Clash(c *gin.Context) {
+// Render returns the subscription of the user owning the token in the requested format
+//
+// Render godoc
+// @Summary Subscription
+// @Description Render subscription of the token owner, format could be clash, singbox or v2rayn
+// @ID Subscription.Render
+// @Tags Subscription
+// @Produce  plain
+// @Param format path string true "Subscription Format"
+// @Param token query string true "Subscription Token"
+// @Param tags query string false "Comma separated tags, only nodes having any of them are included"
+// @Success 200 {string} string
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /subscription/{format} [get]
+func Render(c *gin.Context) {
+	renderer, err := sub.Lookup(c.Param("format"))
+	if err != nil {
+		log.Log.WithError(err).Info("Renderer Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+
+	subscription, ok := resolve(c)
+	if !ok {
+		return
+	}
+
+	// Render into buffer first so a failed render never leaves a partial body
+	var b bytes.Buffer
+	if err := renderer.Render(&b, subscription); err != nil {
+		log.Log.WithError(err).Error("Error Rendering Subscription")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	setHeaders(c, subscription.User)
+	c.Data(http.StatusOK, renderer.ContentType(), b.Bytes())
+	return
+}
+
+// setHeaders adds the headers clients like clash read to show quota and
+// profile name, see https://github.com/crossutility/Quantumult/blob/master/extra-subscription-feature.md
+func setHeaders(c *gin.Context, user *models.User) {
+	used := user.CurrentTraffic
+	if used < 0 {
+		used = 0
+	}
+	total := user.MaxTraffic
+	if total < 0 {
+		total = 0
+	}
+
+	// Logs hold real traffic while quota is charged with node multipliers,
+	// so the charged traffic is split by the real upload to download ratio
+	upload, download := uint64(0), uint64(used)
+	up, down, err := models.TrafficSum(orm.DB.Where("user_id = ?", user.ID), user.CycleStart())
+	if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+	} else if up+down > 0 {
+		upload = uint64(math.Round(float64(used) * float64(up) / float64(up+down)))
+		download = uint64(used) - upload
+	}
+	userinfo := fmt.Sprintf("upload=%d; download=%d; total=%d", upload, download, total)
+	if user.ExpireAt != nil {
+		userinfo += fmt.Sprintf("; expire=%d", user.ExpireAt.Unix())
+	}
+	c.Header("subscription-userinfo", userinfo)
+
+	// Update interval in hours
+	c.Header("profile-update-interval", models.GetOption("subscription_update_interval", "24"))
+
+	siteName := models.GetOption("site_name", "RayDash")
+	c.Header("content-disposition", "attachment; filename*=UTF-8''"+url.PathEscape(siteName))
+}
+
+// resolve find the user by subscription token and walk through
+// user's groups and nodes to collect the services belong to the user.
+// Response is written when it fails, so caller should simply return.
+func resolve(c *gin.Context) (*sub.Subscription, bool) {
 
 	subToken := c.Query("token")
 	var user models.User
 	if err := orm.DB.Preload("Groups").Where("subscription_token = ?", subToken).First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
 		log.Log.Info("No Such User in Database")
-		c.Status(http.StatusNotFound)
-		return
+		c.JSON(http.StatusNotFound, gin.H{"error": "Invalid subscription token"})
+		return nil, false
 	} else if err != nil {
 		log.Log.WithFields(logrus.Fields{
 			"error": err.Error(),
 		}).Error("Database Error")
 		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-		return
+		return nil, false
+	}
+	profile, err := findProfile(&user)
+	if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return nil, false
+	}
+	subscription := &sub.Subscription{
+		User:    &user,
+		Nodes:   make(map[uint64]*models.Node),
+		Profile: profile,
 	}
+
+	// Suspended users still get quota in headers but no service
+	now := time.Now()
+	if !user.Active(now) {
+		return subscription, true
+	}
+
+	// Nodes missing heartbeats are left out when option subscription_skip_offline is true
+	skipOffline, _ := strconv.ParseBool(models.GetOption("subscription_skip_offline", "false"))
+
 	var nodes []*models.Node
 	for _, g := range user.Groups {
 		if err := orm.DB.Preload("Nodes").Where("ID = ?", g.ID).First(&g).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, false
 		}
 		nodes = append(nodes, g.Nodes...)
 
 	}
-	var services []*models.Service
+	models.SortNodes(nodes)
+	tags := models.ParseTags(c.Query("tags"))
 	for _, n := range nodes {
-		if err := orm.DB.Preload("Services").Where("ID = ?", n.ID).First(&n).Error; err != nil {
+		// A node could be reached through several groups
+		if _, ok := subscription.Nodes[n.ID]; ok {
+			continue
+		}
+		if !n.Available(now) || (skipOffline && !n.Online) {
+			continue
+		}
+		if !n.HasAnyTag(tags) {
+			continue
+		}
+		if err := orm.DB.Preload("Services", "user_id = ?", user.ID).Where("ID = ?", n.ID).First(&n).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
-			return
+			return nil, false
 		}
-		services = append(services, n.Services...)
+		subscription.Services = append(subscription.Services, n.Services...)
+		subscription.Nodes[n.ID] = n
 	}
+	return subscription, true
+}
 
-	var b bytes.Buffer
-	clash.Generate(services, &b)
-	c.String(http.StatusOK, b.String())
-	return
+// findProfile returns the profile of the user group with the lowest id having one,
+// otherwise the one in option default_profile. Nil means no profile.
+func findProfile(user *models.User) (*models.Profile, error) {
+	pid := models.GetOption("default_profile", "")
+	var gid uint64
+	for _, g := range user.Groups {
+		if g.ProfileID != nil && (gid == 0 || g.ID < gid) {
+			gid = g.ID
+			pid = strconv.FormatUint(*g.ProfileID, 10)
+		}
+	}
+	if pid == "" {
+		return nil, nil
+	}
+
+	var profile models.Profile
+	if err := orm.DB.Where("id = ?", pid).First(&profile).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("profileID", pid).Warn("Profile Not Found")
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	return &profile, nil
 }
@@ -0,0 +1,248 @@
+package subscription_test
+
+import (
+	"encoding/base64"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	sub "github.com/coolray-dev/raydash/modules/subscription"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+// Create a fake user owning a service for testing
+var user models.User
+var service models.Service
+
+type brokenRenderer struct{}
+
+func (r *brokenRenderer) ContentType() string { return "text/plain" }
+
+func (r *brokenRenderer) Render(w io.Writer, s *sub.Subscription) error {
+	return errors.New("broken")
+}
+
+func TestMain(m *testing.M) {
+
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	gofakeit.Struct(&user)
+	user.SubscriptionToken = gofakeit.UUID()
+	orm.DB.Create(&user)
+
+	node := models.Node{
+		Name: gofakeit.Word(),
+		Host: gofakeit.DomainName(),
+	}
+	orm.DB.Create(&node)
+
+	group := models.Group{
+		Name:  gofakeit.Word(),
+		Users: []*models.User{&user},
+		Nodes: []*models.Node{&node},
+	}
+	orm.DB.Create(&group)
+
+	service = models.Service{
+		Name:   gofakeit.Word(),
+		UserID: user.ID,
+		NodeID: node.ID,
+		Host:   node.Host,
+		Port:   443,
+	}
+	service.VmessUser.UUID = gofakeit.UUID()
+	orm.DB.Create(&service)
+
+	sub.Register("broken", &brokenRenderer{})
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestRender(t *testing.T) {
+	router := testutils.GetRouter()
+
+	cases := []struct {
+		Name   string
+		Format string
+		Token  string
+		Status int
+	}{
+		{"Clash", "clash", user.SubscriptionToken, http.StatusOK},
+		{"V2RayN", "v2rayn", user.SubscriptionToken, http.StatusOK},
+		{"SingBox", "singbox", user.SubscriptionToken, http.StatusOK},
+		{"Unknown format", gofakeit.Word(), user.SubscriptionToken, http.StatusNotFound},
+		{"Invalid token", "clash", gofakeit.UUID(), http.StatusNotFound},
+		{"Broken renderer", "broken", user.SubscriptionToken, http.StatusInternalServerError},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/subscription/"+c.Format+"?token="+c.Token, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(c.Status, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+
+			assert.Contains(w.Header().Get("subscription-userinfo"), "download=")
+			assert.NotEmpty(w.Header().Get("profile-update-interval"))
+			assert.Contains(w.Header().Get("content-disposition"), "filename*=UTF-8''")
+
+			body := w.Body.String()
+			if c.Format == "v2rayn" {
+				dec, err := base64.StdEncoding.DecodeString(body)
+				assert.Nil(err)
+				body = string(dec)
+				assert.True(strings.HasPrefix(body, "vmess://"))
+				return
+			}
+			assert.Contains(body, service.VmessUser.UUID)
+		})
+	}
+}
+
+func TestRenderSuspended(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	// Expired plan hides services even before anything enforced it
+	expireAt := time.Now().Add(-time.Hour)
+	orm.DB.Model(&user).UpdateColumn("expire_at", &expireAt)
+	defer orm.DB.Model(&user).UpdateColumn("expire_at", nil)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/subscription/clash?token="+user.SubscriptionToken, nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.Contains(w.Header().Get("subscription-userinfo"), "expire=")
+	assert.NotContains(w.Body.String(), service.VmessUser.UUID)
+}
+
+func TestRenderSkipOffline(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	option := models.Option{Name: "subscription_skip_offline", Value: "true"}
+	orm.DB.Create(&option)
+	defer orm.DB.Delete(&option)
+
+	render := func() string {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest("GET", "/v1/subscription/clash?token="+user.SubscriptionToken, nil)
+		router.ServeHTTP(w, req)
+		assert.Equal(http.StatusOK, w.Code)
+		return w.Body.String()
+	}
+
+	// Node never sent a heartbeat
+	assert.NotContains(render(), service.VmessUser.UUID)
+
+	now := time.Now()
+	orm.DB.Model(&models.Node{}).Where("id = ?", service.NodeID).UpdateColumn("last_seen_at", &now)
+	defer orm.DB.Model(&models.Node{}).Where("id = ?", service.NodeID).UpdateColumn("last_seen_at", nil)
+	assert.Contains(render(), service.VmessUser.UUID)
+}
+
+func TestRenderTags(t *testing.T) {
+	router := testutils.GetRouter()
+
+	var node models.Node
+	orm.DB.First(&node, service.NodeID)
+	node.Tags = []string{"hk", "premium"}
+	orm.DB.Save(&node)
+	defer orm.DB.Model(&node).UpdateColumn("tags", "")
+
+	cases := []struct {
+		Name     string
+		Tags     string
+		Included bool
+	}{
+		{"No filter", "", true},
+		{"Matching tag", "jp,HK", true},
+		{"Other tag", "jp", false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/subscription/clash?token="+user.SubscriptionToken+"&tags="+c.Tags, nil)
+			router.ServeHTTP(w, req)
+			assert.Equal(http.StatusOK, w.Code)
+			if c.Included {
+				assert.Contains(w.Body.String(), service.VmessUser.UUID)
+			} else {
+				assert.NotContains(w.Body.String(), service.VmessUser.UUID)
+			}
+		})
+	}
+}
+
+func TestRenderMaintenance(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	orm.DB.Model(&models.Node{}).Where("id = ?", service.NodeID).UpdateColumn("state", models.NodeMaintenance)
+	defer orm.DB.Model(&models.Node{}).Where("id = ?", service.NodeID).UpdateColumn("state", models.NodeActive)
+
+	w := httptest.NewRecorder()
+	req, _ := http.NewRequest("GET", "/v1/subscription/clash?token="+user.SubscriptionToken, nil)
+	router.ServeHTTP(w, req)
+
+	assert.Equal(http.StatusOK, w.Code)
+	assert.NotContains(w.Body.String(), service.VmessUser.UUID)
+}
+
+func TestUserinfo(t *testing.T) {
+	assert := assertlib.New(t)
+	router := testutils.GetRouter()
+
+	// Traffic of the current cycle is one third upload, older traffic does not count
+	cycle := time.Now().UTC().Truncate(time.Hour)
+	orm.DB.Model(&user).UpdateColumn("last_reset_at", &cycle)
+	defer orm.DB.Model(&user).UpdateColumn("last_reset_at", nil)
+	assert.Nil(models.AddTrafficLog(orm.DB, user.ID, service.ID, service.NodeID, cycle, 100, 200))
+	assert.Nil(models.AddTrafficLog(orm.DB, user.ID, service.ID, service.NodeID, cycle.Add(-2*time.Hour), 1000, 0))
+	defer orm.DB.Where("user_id = ?", user.ID).Delete(&models.TrafficLog{})
+
+	cases := []struct {
+		Name     string
+		Charged  int64
+		Userinfo string
+	}{
+		{"Real traffic", 300, "upload=100; download=200; total=1000"},
+		{"Multiplier", 600, "upload=200; download=400; total=1000"},
+		{"Nothing charged", 0, "upload=0; download=0; total=1000"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			orm.DB.Model(&user).UpdateColumns(map[string]interface{}{"current_traffic": c.Charged, "max_traffic": 1000})
+
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/subscription/clash?token="+user.SubscriptionToken, nil)
+			router.ServeHTTP(w, req)
+
+			assert.Equal(http.StatusOK, w.Code)
+			assert.Equal(c.Userinfo, w.Header().Get("subscription-userinfo"))
+		})
+	}
+}
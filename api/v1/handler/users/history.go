@@ -0,0 +1,70 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+type historyResponse struct {
+	Interval string                `json:"interval"`
+	History  []models.TrafficPoint `json:"history"`
+}
+
+// History returns traffic of a user aggregated by hour or day
+//
+// History godoc
+// @Summary User traffic history
+// @Description Traffic of a user between from and to by interval, optionally on a single node
+// @ID users.History
+// @Security ApiKeyAuth
+// @Tags Users
+// @Produce  json
+// @Param username path string true "Username"
+// @Param from query int false "Unix timestamp, defaults to a day or 30 days before to"
+// @Param to query int false "Unix timestamp, defaults to now"
+// @Param interval query string false "hour (default) or day"
+// @Param nid query uint false "Node ID"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} historyResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/traffic/history [get]
+func History(c *gin.Context) {
+	var user models.User
+	username := c.Param("username")
+	if err := orm.DB.Where("username = ?", username).First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Log.WithField("username", username).Info("User Not Found")
+		c.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	} else if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := orm.DB.Where("user_id = ?", user.ID)
+	if nid, ok := c.Get("nid"); ok {
+		query = query.Where("node_id = ?", nid)
+	}
+	interval := c.GetString("interval")
+	history, err := models.TrafficHistory(query, c.GetTime("from"), c.GetTime("to"), interval)
+	if err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusOK, historyResponse{
+		Interval: interval,
+		History:  history,
+	})
+	return
+}
@@ -0,0 +1,40 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"github.com/coolray-dev/raydash/api/v1/handler"
+	"github.com/coolray-dev/raydash/modules/lockout"
+)
+
+// Unlock lifts the lockout of a user after too many failed logins
+//
+// Unlock godoc
+// @Summary Unlock User
+// @Description Clear failed login attempts of a user so the user could login right away
+// @ID users.Unlock
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param Authorization header string true "Access Token"
+// @Success 204
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/lock [delete]
+func Unlock(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	if err := lockout.Default.Unlock(user.Username); err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+	return
+}
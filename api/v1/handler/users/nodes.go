@@ -2,6 +2,7 @@ package users
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/coolray-dev/raydash/api/v1/handler"
 	orm "github.com/coolray-dev/raydash/database"
@@ -13,7 +14,7 @@ type nodesResponse struct {
 	Nodes []*model.Node `json:"nodes"`
 }
 
-// Nodes return all nodes a user has
+// Nodes return all available nodes a user has
 //
 // Nodes godoc
 // @Summary List all nodes
@@ -36,14 +37,19 @@ func Nodes(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
 		return
 	}
+	now := time.Now()
 	var nodes []*model.Node
 	for _, g := range user.Groups {
 		if err := orm.DB.Preload("Nodes").Where("ID = ?", g.ID).First(&g).Error; err != nil {
 			c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
 			return
 		}
-		nodes = append(nodes, g.Nodes...)
-
+		// Nodes in maintenance or disabled are hidden from users
+		for _, n := range g.Nodes {
+			if n.Available(now) {
+				nodes = append(nodes, n)
+			}
+		}
 	}
 	c.JSON(http.StatusOK, &nodesResponse{
 		Nodes: nodes,
@@ -0,0 +1,76 @@
+package users
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/api/v1/handler"
+	orm "github.com/coolray-dev/raydash/database"
+	model "github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/password"
+)
+
+type passwordRequest struct {
+	Password    string `json:"password" binding:"required"` // current password
+	NewPassword string `json:"new_password" binding:"required"`
+}
+
+// Password changes password of a user and revokes all sessions
+//
+// Password godoc
+// @Summary Change Password
+// @Description Change password with the current one, every session is revoked
+// @ID users.Password
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param password body passwordRequest true "Current and New Password"
+// @Param Authorization header string true "Access Token"
+// @Success 204
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/password [put]
+func Password(c *gin.Context) {
+	var json passwordRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	if ok, _, err := password.Verify(json.Password, user.Password); err != nil {
+		log.Log.WithError(err).WithField("username", user.Username).Error("Error Verifying Password")
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	} else if !ok {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: "Wrong password"})
+		return
+	}
+
+	hash, err := password.Hash(json.NewPassword)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	err = orm.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(user).UpdateColumn("password", hash).Error; err != nil {
+			return err
+		}
+		return model.RevokeSessions(tx, user.ID)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+	return
+}
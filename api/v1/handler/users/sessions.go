@@ -0,0 +1,128 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/api/v1/handler"
+	orm "github.com/coolray-dev/raydash/database"
+	model "github.com/coolray-dev/raydash/models"
+)
+
+type sessionsResponse struct {
+	Sessions []model.Session `json:"sessions"`
+}
+
+// Sessions lists where a user is logged in
+//
+// Sessions godoc
+// @Summary List Sessions
+// @Description Return unexpired sessions of a user, most recently used first
+// @ID users.Sessions
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} sessionsResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/sessions [get]
+func Sessions(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	var sessions []model.Session
+	if err := orm.DB.Scopes(model.ActiveSessions).
+		Where("user_id = ?", user.ID).
+		Order("last_used_at desc").
+		Find(&sessions).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, &sessionsResponse{
+		Sessions: sessions,
+	})
+	return
+}
+
+// RevokeSession logs a user out of one session
+//
+// RevokeSession godoc
+// @Summary Revoke Session
+// @Description Revoke a session so its refresh token stops working
+// @ID users.RevokeSession
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param sessid path uint true "Session ID"
+// @Param Authorization header string true "Access Token"
+// @Success 204
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/sessions/{sessid} [delete]
+func RevokeSession(c *gin.Context) {
+	sessid, err := strconv.ParseUint(c.Param("sessid"), 10, 64)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	// Scoped to the user so nobody revokes sessions of others by id
+	var session model.Session
+	if err := orm.DB.Where("id = ? AND user_id = ?", sessid, user.ID).First(&session).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, &handler.ErrorResponse{Error: err.Error()})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := orm.DB.Delete(&session).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+	return
+}
+
+// RevokeSessions logs a user out everywhere
+//
+// RevokeSessions godoc
+// @Summary Revoke All Sessions
+// @Description Revoke every session of a user, access tokens already issued last until they expire
+// @ID users.RevokeSessions
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param Authorization header string true "Access Token"
+// @Success 204
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/sessions [delete]
+func RevokeSessions(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	if err := model.RevokeSessions(orm.DB, user.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+	return
+}
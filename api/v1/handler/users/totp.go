@@ -0,0 +1,245 @@
+package users
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/api/v1/handler"
+	orm "github.com/coolray-dev/raydash/database"
+	model "github.com/coolray-dev/raydash/models"
+)
+
+type enrollTOTPResponse struct {
+	Secret string `json:"secret"`
+	URI    string `json:"uri"` // otpauth:// uri for qr codes
+}
+
+type totpRequest struct {
+	Code string `json:"code" binding:"required"`
+}
+
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// EnrollTOTP generates a totp secret which takes effect after ConfirmTOTP
+//
+// EnrollTOTP godoc
+// @Summary Enroll TOTP
+// @Description Generate a TOTP secret pending confirmation
+// @ID users.EnrollTOTP
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} enrollTOTPResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/totp [post]
+func EnrollTOTP(c *gin.Context) {
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, &handler.ErrorResponse{Error: "Two-factor authentication already enabled"})
+		return
+	}
+
+	uri, err := user.EnrollTOTP()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := user.SaveTOTP(orm.DB); err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, &enrollTOTPResponse{
+		Secret: user.TOTPSecret,
+		URI:    uri,
+	})
+	return
+}
+
+// ConfirmTOTP enables the enrolled secret with its first code
+//
+// ConfirmTOTP godoc
+// @Summary Confirm TOTP
+// @Description Enable two-factor authentication with the first code of the enrolled secret and return recovery codes
+// @ID users.ConfirmTOTP
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param code body totpRequest true "TOTP Code"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 409 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/totp [put]
+func ConfirmTOTP(c *gin.Context) {
+	var json totpRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	if user.TOTPEnabled {
+		c.JSON(http.StatusConflict, &handler.ErrorResponse{Error: "Two-factor authentication already enabled"})
+		return
+	}
+
+	codes, err := user.ConfirmTOTP(json.Code, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	} else if codes == nil {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: "Invalid code"})
+		return
+	}
+	if err := user.SaveTOTP(orm.DB); errors.Is(err, model.ErrCodeUsed) {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: "Invalid code"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, &recoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+	return
+}
+
+// DisableTOTP turns two-factor authentication off with a current code
+//
+// DisableTOTP godoc
+// @Summary Disable TOTP
+// @Description Disable two-factor authentication, not allowed when a group of the user requires it
+// @ID users.DisableTOTP
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param code body totpRequest true "TOTP or Recovery Code"
+// @Param Authorization header string true "Access Token"
+// @Success 204
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 403 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/totp [delete]
+func DisableTOTP(c *gin.Context) {
+	var json totpRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+
+	forced, err := user.MFAForced(orm.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if forced {
+		c.JSON(http.StatusForbidden, &handler.ErrorResponse{Error: "Two-factor authentication is required by group"})
+		return
+	}
+	if !user.CheckSecondFactor(json.Code, time.Now()) {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: "Invalid code"})
+		return
+	}
+
+	user.DisableTOTP()
+	if err := user.SaveTOTP(orm.DB); errors.Is(err, model.ErrCodeUsed) {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: "Invalid code"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.Status(http.StatusNoContent)
+	return
+}
+
+// RecoveryCodes replaces recovery codes of a user with a current code
+//
+// RecoveryCodes godoc
+// @Summary Regenerate Recovery Codes
+// @Description Replace all recovery codes, old ones stop working
+// @ID users.RecoveryCodes
+// @Security ApiKeyAuth
+// @Tags Users
+// @Accept  json
+// @Produce  json
+// @Param username path string true "Username"
+// @Param code body totpRequest true "TOTP or Recovery Code"
+// @Param Authorization header string true "Access Token"
+// @Success 200 {object} recoveryCodesResponse
+// @Failure 400 {object} handler.ErrorResponse
+// @Failure 404 {object} handler.ErrorResponse
+// @Failure 500 {object} handler.ErrorResponse
+// @Router /users/{username}/totp/recovery [post]
+func RecoveryCodes(c *gin.Context) {
+	var json totpRequest
+	if err := c.ShouldBindJSON(&json); err != nil {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	user, ok := findUser(c)
+	if !ok {
+		return
+	}
+	if !user.CheckSecondFactor(json.Code, time.Now()) {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: "Invalid code"})
+		return
+	}
+
+	codes, err := user.NewRecoveryCodes()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	if err := user.SaveTOTP(orm.DB); errors.Is(err, model.ErrCodeUsed) {
+		c.JSON(http.StatusBadRequest, &handler.ErrorResponse{Error: "Invalid code"})
+		return
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, &recoveryCodesResponse{
+		RecoveryCodes: codes,
+	})
+	return
+}
+
+// findUser loads the user of path param and responds on failure
+func findUser(c *gin.Context) (*model.User, bool) {
+	var user model.User
+	if err := orm.DB.Where("username = ?", c.Param("username")).First(&user).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		c.JSON(http.StatusNotFound, &handler.ErrorResponse{Error: err.Error()})
+		return nil, false
+	} else if err != nil {
+		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{Error: err.Error()})
+		return nil, false
+	}
+	return &user, true
+}
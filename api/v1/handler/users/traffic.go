@@ -2,6 +2,7 @@ package users
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/coolray-dev/raydash/api/v1/handler"
 	orm "github.com/coolray-dev/raydash/database"
@@ -16,6 +17,7 @@ type trafficResponse struct {
 type trafficRequest struct {
 	CurrentTraffic int64 `json:"current_traffic"`
 	MaxTraffic     int64 `json:"max_traffic"`
+	ExpireAt       int64 `json:"expire_at"` // unix timestamp, negative value clears it
 }
 
 // Traffic receive traffic info and update it
@@ -58,6 +60,15 @@ func Traffic(c *gin.Context) {
 	if json.MaxTraffic != 0 {
 		user.MaxTraffic = json.MaxTraffic
 	}
+	if json.ExpireAt > 0 {
+		expireAt := time.Unix(json.ExpireAt, 0)
+		user.ExpireAt = &expireAt
+	} else if json.ExpireAt < 0 {
+		user.ExpireAt = nil
+	}
+
+	// Resetting or raising quota brings suspended user back
+	user.Enforce(time.Now())
 
 	if err := orm.DB.Save(&user).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, &handler.ErrorResponse{
@@ -13,6 +13,7 @@ import (
 	"github.com/coolray-dev/raydash/modules/casbin"
 	"github.com/coolray-dev/raydash/modules/jwt"
 	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/metrics"
 	"github.com/gin-gonic/gin"
 	"gorm.io/gorm"
 )
@@ -126,6 +127,19 @@ func Authorize() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
+
+		// Let handlers tell who is calling
+		c.Set("role", role)
+		c.Set("username", "")
+		c.Set("isAdmin", false)
+		if role == "user" {
+			c.Set("username", subject)
+			isAdmin, err := casbin.Enforcer.HasRoleForUser(subject, "group::admin")
+			if err != nil {
+				log.Log.WithError(err).Error("Casbin Error")
+			}
+			c.Set("isAdmin", isAdmin)
+		}
 	}
 }
 
@@ -190,6 +204,7 @@ func casbinAuthorize(role, sub, obj, act string) (bool, error) {
 		return false, err
 	}
 	if !res {
+		metrics.Deny(role)
 		log.Log.WithField("Role", role).WithField("Subject", sub).Debug("Access Denied")
 	} else {
 		log.Log.WithField("Role", role).WithField("Subject", sub).Debug("Access Allowed")
@@ -0,0 +1,56 @@
+package middleware
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"time"
+
+	"github.com/coolray-dev/raydash/modules/metrics"
+	"github.com/coolray-dev/raydash/modules/setting"
+	"github.com/gin-gonic/gin"
+)
+
+// Metrics records count and latency of every request by matched route
+func Metrics() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		// Use route pattern instead of path to keep label cardinality low
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+// MetricsAccess allows requests carrying metrics.token as bearer token or
+// coming from an address in metrics.allow, which is a list of IPs or CIDRs.
+// Only loopback is allowed when neither is configured. The address is the
+// one found by RemoteIP, forwarded headers from untrusted peers are ignored.
+func MetricsAccess() gin.HandlerFunc {
+	token := setting.Config.GetString("metrics.token")
+	allow := setting.Config.GetStringSlice("metrics.allow")
+	if token == "" && len(allow) == 0 {
+		allow = []string{"127.0.0.0/8", "::1/128"}
+	}
+
+	nets := parseNets(allow)
+
+	return func(c *gin.Context) {
+		if token != "" {
+			header := []byte(c.GetHeader("Authorization"))
+			if subtle.ConstantTimeCompare(header, []byte("Bearer "+token)) == 1 {
+				c.Next()
+				return
+			}
+		}
+		if containsIP(nets, c.GetString("remoteIP")) {
+			c.Next()
+			return
+		}
+		c.JSON(http.StatusForbidden, gin.H{"error": "Permission Denied"})
+		c.Abort()
+	}
+}
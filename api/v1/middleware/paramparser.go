@@ -58,3 +58,48 @@ func parseParamTime(varName string, defaultParam string, paramName string, c *gi
 	return
 
 }
+
+// ParseRange parse time range and interval of history queries, from and to are unix timestamps.
+// to defaults to now, from defaults to a day before to for hourly history and 30 days for daily one.
+func ParseRange() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		interval := c.DefaultQuery("interval", "hour")
+		var span time.Duration
+		switch interval {
+		case "hour":
+			span = 24 * time.Hour
+		case "day":
+			span = 30 * 24 * time.Hour
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Param 'interval' should be hour or day"})
+			c.Abort()
+			return
+		}
+		c.Set("interval", interval)
+
+		to, err := parseParamTime("to", "0", "to", c)
+		if err != nil {
+			c.Abort()
+			return
+		}
+		if to.IsZero() {
+			to = time.Now()
+			c.Set("to", to)
+		}
+		from, err := parseParamTime("from", "0", "from", c)
+		if err != nil {
+			c.Abort()
+			return
+		}
+		if from.IsZero() {
+			from = to.Add(-span)
+			c.Set("from", from)
+		}
+		if !from.Before(to) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Param 'from' should be before 'to'"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
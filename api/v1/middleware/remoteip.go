@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net"
+	"strings"
+
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/setting"
+	"github.com/gin-gonic/gin"
+)
+
+// RemoteIP stores the address of the client as "remoteIP" for later use in
+// allowlists, login throttling and sessions. Unlike c.ClientIP() it only
+// believes X-Forwarded-For when the connection comes from an address in
+// app.trustedProxies, and then takes the rightmost untrusted hop, as every
+// hop before that could have been written by the client.
+func RemoteIP() gin.HandlerFunc {
+	trusted := parseNets(setting.Config.GetStringSlice("app.trustedProxies"))
+
+	return func(c *gin.Context) {
+		ip, _, err := net.SplitHostPort(c.Request.RemoteAddr)
+		if err != nil {
+			ip = c.Request.RemoteAddr
+		}
+		if containsIP(trusted, ip) {
+			hops := strings.Split(c.GetHeader("X-Forwarded-For"), ",")
+			for i := len(hops) - 1; i >= 0; i-- {
+				hop := strings.TrimSpace(hops[i])
+				if net.ParseIP(hop) == nil {
+					break
+				}
+				ip = hop
+				if !containsIP(trusted, hop) {
+					break
+				}
+			}
+		}
+		c.Set("remoteIP", ip)
+	}
+}
+
+// parseNets parses a list of IPs or CIDRs, single IPs are taken as host
+// networks and invalid entries are logged and skipped
+func parseNets(list []string) []*net.IPNet {
+	var nets []*net.IPNet
+	for _, a := range list {
+		if !strings.Contains(a, "/") {
+			if strings.Contains(a, ":") {
+				a += "/128"
+			} else {
+				a += "/32"
+			}
+		}
+		_, n, err := net.ParseCIDR(a)
+		if err != nil {
+			log.Log.WithError(err).Error("Invalid Network Entry")
+			continue
+		}
+		nets = append(nets, n)
+	}
+	return nets
+}
+
+func containsIP(nets []*net.IPNet, s string) bool {
+	ip := net.ParseIP(s)
+	if ip == nil {
+		return false
+	}
+	for _, n := range nets {
+		if n.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
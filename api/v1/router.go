@@ -6,10 +6,12 @@ import (
 	"github.com/coolray-dev/raydash/api/v1/handler/groups"
 	"github.com/coolray-dev/raydash/api/v1/handler/nodes"
 	"github.com/coolray-dev/raydash/api/v1/handler/options"
+	"github.com/coolray-dev/raydash/api/v1/handler/profiles"
 	"github.com/coolray-dev/raydash/api/v1/handler/services"
 	"github.com/coolray-dev/raydash/api/v1/handler/subscription"
 	"github.com/coolray-dev/raydash/api/v1/handler/users"
 	"github.com/coolray-dev/raydash/api/v1/middleware"
+	"github.com/coolray-dev/raydash/modules/metrics"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 )
@@ -24,11 +26,20 @@ func SetupRouter(router *gin.Engine, c *cors.Config) {
 	setCORSHeaders(c)
 	router.Use(cors.New(*c))
 
+	// Client address, before anything that allowlists or throttles by it
+	router.Use(middleware.RemoteIP())
+
 	// Log Middleware
 	router.Use(middleware.Log())
 
+	// Metrics Middleware, before Authorize so denied requests are counted
+	router.Use(middleware.Metrics())
+
 	router.Use(middleware.Authorize())
 
+	// Prometheus metrics, guarded by its own token or allowlist
+	router.GET("/metrics", middleware.MetricsAccess(), gin.WrapH(metrics.Handler()))
+
 	v1 := router.Group("/v1")
 
 	// Finally Setup Routes
@@ -45,13 +56,25 @@ func setupRoutes(router *gin.RouterGroup) {
 		usersAPI.PATCH("/:username", users.Update)
 		usersAPI.DELETE("/:username", users.Destroy)
 		usersAPI.PATCH("/:username/traffic", users.Traffic)
+		usersAPI.GET("/:username/traffic/history", middleware.ParseParams(), middleware.ParseRange(), users.History)
 		usersAPI.GET("/:username/groups", users.Groups)
 		usersAPI.GET("/:username/nodes", users.Nodes)
 		usersAPI.GET("/:username/services", users.Services)
+		usersAPI.POST("/:username/totp", users.EnrollTOTP)
+		usersAPI.PUT("/:username/totp", users.ConfirmTOTP)
+		usersAPI.DELETE("/:username/totp", users.DisableTOTP)
+		usersAPI.POST("/:username/totp/recovery", users.RecoveryCodes)
+		usersAPI.DELETE("/:username/lock", users.Unlock)
+		usersAPI.PUT("/:username/password", users.Password)
+		usersAPI.GET("/:username/sessions", users.Sessions)
+		usersAPI.DELETE("/:username/sessions", users.RevokeSessions)
+		usersAPI.DELETE("/:username/sessions/:sessid", users.RevokeSession)
 	}
 
 	router.POST("/register", authentication.Register)
 	router.POST("/login", authentication.Login)
+	router.POST("/login/mfa", authentication.LoginMFA)
+	router.POST("/login/mfa/enroll", authentication.EnrollMFA)
 	router.DELETE("/logout", authentication.Logout)
 	router.POST("/refresh", authentication.RefreshToken)
 
@@ -69,7 +92,16 @@ func setupRoutes(router *gin.RouterGroup) {
 		nodesAPI.DELETE("/:nid", nodes.Destroy)
 		nodesAPI.GET("/:nid/users", nodes.Users)
 		nodesAPI.PATCH("/:nid/users/:username/traffic", nodes.Traffic)
+		nodesAPI.POST("/:nid/traffic", nodes.Report)
+		nodesAPI.POST("/:nid/heartbeat", nodes.Heartbeat)
+		nodesAPI.PUT("/:nid/state", nodes.State)
+		nodesAPI.GET("/:nid/traffic/history", middleware.ParseParams(), middleware.ParseRange(), nodes.History)
 		nodesAPI.GET("/:nid/services", nodes.Services)
+		nodesAPI.GET("/:nid/groups", nodes.Groups)
+		nodesAPI.PUT("/:nid/groups", nodes.SetGroups)
+		nodesAPI.PATCH("/:nid/groups", nodes.AppendGroups)
+		nodesAPI.DELETE("/:nid/groups/:gid", nodes.RemoveGroup)
+		nodesAPI.GET("/:nid/config", nodes.Config)
 		nodesAPI.GET("/:nid/token", nodes.AccessToken)
 		nodesAPI.POST("/:nid/token", nodes.GenerateToken)
 	}
@@ -91,6 +123,10 @@ func setupRoutes(router *gin.RouterGroup) {
 		groupsAPI.GET("/:gid/users", groups.Users)
 		groupsAPI.PATCH("/:gid/users", groups.AppendUser)
 		groupsAPI.DELETE("/:gid/users/:username", groups.RemoveUser)
+		groupsAPI.GET("/:gid/nodes", groups.Nodes)
+		groupsAPI.PUT("/:gid/nodes", groups.SetNodes)
+		groupsAPI.PATCH("/:gid/nodes", groups.AppendNodes)
+		groupsAPI.DELETE("/:gid/nodes/:nid", groups.RemoveNode)
 	}
 
 	optionsAPI := router.Group("/options")
@@ -99,6 +135,15 @@ func setupRoutes(router *gin.RouterGroup) {
 		optionsAPI.PUT("/:name", options.Update)
 	}
 
+	profilesAPI := router.Group("/profiles")
+	{
+		profilesAPI.GET("", profiles.Index)
+		profilesAPI.POST("", profiles.Create)
+		profilesAPI.GET("/:pid", profiles.Show)
+		profilesAPI.PATCH("/:pid", profiles.Update)
+		profilesAPI.DELETE("/:pid", profiles.Destroy)
+	}
+
 	announcementsAPI := router.Group("/announcements")
 	{
 		announcementsAPI.GET("", middleware.ParseParams(), announcements.Index)
@@ -109,7 +154,7 @@ func setupRoutes(router *gin.RouterGroup) {
 	}
 	subscriptionAPI := router.Group("/subscription")
 	{
-		subscriptionAPI.GET("/clash", subscription.Clash)
+		subscriptionAPI.GET("/:format", subscription.Render)
 	}
 
 	return
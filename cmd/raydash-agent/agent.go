@@ -0,0 +1,285 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/coolray-dev/raydash/modules/log"
+)
+
+// Version of the agent, reported in heartbeats
+var Version = "dev"
+
+// Agent keeps the v2ray/xray on a node in sync with the panel
+type Agent struct {
+	Panel   string // base url of the panel, such as https://panel.example.com
+	NodeID  uint64
+	Token   string // node access token
+	Format  string // v2ray or xray
+	Config  string // path to write the config to
+	Reload  string // shell command to run after config changed
+	Stats   StatsSource
+	Client  *http.Client
+	started time.Time
+	etag    string
+	pending map[uint64]*Traffic // traffic failed to report, sent again next time
+}
+
+// NewAgent returns an Agent instance
+func NewAgent(panel string, nid uint64, token string) *Agent {
+	var agent Agent
+	agent.Panel = strings.TrimRight(panel, "/")
+	agent.NodeID = nid
+	agent.Token = token
+	agent.Client = &http.Client{Timeout: 30 * time.Second}
+	agent.started = time.Now()
+	agent.pending = make(map[uint64]*Traffic)
+	return &agent
+}
+
+// RunOnce syncs config, reports traffic and sends a heartbeat,
+// every step runs even if a previous one failed
+func (a *Agent) RunOnce() error {
+	var errs []string
+	if err := a.SyncConfig(); err != nil {
+		log.Log.WithError(err).Error("Error Syncing Config")
+		errs = append(errs, err.Error())
+	}
+	online, err := a.ReportTraffic()
+	if err != nil {
+		log.Log.WithError(err).Error("Error Reporting Traffic")
+		errs = append(errs, err.Error())
+	}
+	if err := a.Heartbeat(online); err != nil {
+		log.Log.WithError(err).Error("Error Sending Heartbeat")
+		errs = append(errs, err.Error())
+	}
+	if len(errs) > 0 {
+		return fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return nil
+}
+
+// SyncConfig fetches config of the node, writes it and runs the reload
+// command when it changed
+func (a *Agent) SyncConfig() error {
+	req, err := a.newRequest("GET", "/config?format="+a.Format, nil)
+	if err != nil {
+		return err
+	}
+	if a.etag != "" {
+		req.Header.Set("If-None-Match", a.etag)
+	}
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotModified {
+		return nil
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, body)
+	}
+
+	if err := writeFile(a.Config, body); err != nil {
+		return err
+	}
+	log.Log.WithField("path", a.Config).Info("Config Updated")
+
+	if a.Reload != "" {
+		out, err := exec.Command("sh", "-c", a.Reload).CombinedOutput()
+		if err != nil {
+			// Keep the etag empty so reload is tried again next time
+			return fmt.Errorf("Error running reload command: %v: %s", err, strings.TrimSpace(string(out)))
+		}
+		log.Log.Info("Reloaded")
+	}
+	a.etag = resp.Header.Get("ETag")
+	return nil
+}
+
+// ReportTraffic collects traffic from stats source and reports it,
+// returns the number of services having traffic
+func (a *Agent) ReportTraffic() (uint, error) {
+	if a.Stats == nil {
+		return 0, nil
+	}
+	traffic, err := a.Stats.Collect()
+	if err != nil {
+		return 0, err
+	}
+	online := uint(len(traffic))
+
+	// Counters are already reset, merge into pending so nothing is lost if report fails
+	for _, t := range traffic {
+		p, ok := a.pending[t.SID]
+		if !ok {
+			p = &Traffic{SID: t.SID}
+			a.pending[t.SID] = p
+		}
+		p.Upload += t.Upload
+		p.Download += t.Download
+	}
+	if len(a.pending) == 0 {
+		return online, nil
+	}
+
+	batch := make([]Traffic, 0, len(a.pending))
+	for _, p := range a.pending {
+		batch = append(batch, *p)
+	}
+	body, err := json.Marshal(map[string]interface{}{"traffic": batch})
+	if err != nil {
+		return online, err
+	}
+	req, err := a.newRequest("POST", "/traffic", bytes.NewReader(body))
+	if err != nil {
+		return online, err
+	}
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return online, err
+	}
+	defer resp.Body.Close()
+	respBody, _ := ioutil.ReadAll(resp.Body)
+
+	switch {
+	case resp.StatusCode == http.StatusCreated:
+		// Services removed since the last sync are skipped by the panel,
+		// the rest of the batch is taken
+		var result struct {
+			Unknown []uint64 `json:"unknown"`
+		}
+		if err := json.Unmarshal(respBody, &result); err == nil && len(result.Unknown) > 0 {
+			log.Log.WithField("services", result.Unknown).Warn("Traffic Of Unknown Services Dropped")
+		}
+	case resp.StatusCode == http.StatusBadRequest:
+		// Panel will never take this batch since it is malformed
+		log.Log.WithFields(logrus.Fields{
+			"services": len(batch),
+			"response": string(respBody),
+		}).Warn("Traffic Rejected, Dropping Batch")
+	default:
+		return online, responseError(resp, respBody)
+	}
+	a.pending = make(map[uint64]*Traffic)
+	return online, nil
+}
+
+// Heartbeat reports status of the node
+func (a *Agent) Heartbeat(online uint) error {
+	body, err := json.Marshal(map[string]interface{}{
+		"uptime":       uptime(a.started),
+		"load":         loadAverage(),
+		"onlineUsers":  online,
+		"agentVersion": Version,
+	})
+	if err != nil {
+		return err
+	}
+	req, err := a.newRequest("POST", "/heartbeat", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	resp, err := a.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	respBody, _ := ioutil.ReadAll(resp.Body)
+	if resp.StatusCode != http.StatusOK {
+		return responseError(resp, respBody)
+	}
+	return nil
+}
+
+func (a *Agent) newRequest(method, path string, body io.Reader) (*http.Request, error) {
+	url := a.Panel + "/v1/nodes/" + strconv.FormatUint(a.NodeID, 10) + path
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer node."+a.Token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return req, nil
+}
+
+func responseError(resp *http.Response, body []byte) error {
+	var e struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(body, &e) == nil && e.Error != "" {
+		return fmt.Errorf("%s %s: %s", resp.Request.Method, resp.Status, e.Error)
+	}
+	return fmt.Errorf("%s %s", resp.Request.Method, resp.Status)
+}
+
+// writeFile replaces the file at once so v2ray never reads a partial config
+func writeFile(path string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// uptime returns uptime of the system, or of the agent where /proc is missing
+func uptime(started time.Time) uint64 {
+	if data, err := ioutil.ReadFile("/proc/uptime"); err == nil {
+		if fields := strings.Fields(string(data)); len(fields) > 0 {
+			if f, err := strconv.ParseFloat(fields[0], 64); err == nil {
+				return uint64(f)
+			}
+		}
+	}
+	return uint64(time.Since(started).Seconds())
+}
+
+// loadAverage returns 1, 5 and 15 minutes load average, empty where /proc is missing
+func loadAverage() []float64 {
+	data, err := ioutil.ReadFile("/proc/loadavg")
+	if err != nil {
+		return nil
+	}
+	fields := strings.Fields(string(data))
+	var load []float64
+	for i := 0; i < 3 && i < len(fields); i++ {
+		f, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil
+		}
+		load = append(load, f)
+	}
+	return load
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	"github.com/coolray-dev/raydash/modules/utils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestParseStats(t *testing.T) {
+	assert := assertlib.New(t)
+
+	traffic, err := parseStats([]byte(`{"stat": [
+		{"name": "user>>>12>>>traffic>>>uplink", "value": "100"},
+		{"name": "user>>>12>>>traffic>>>downlink", "value": 200},
+		{"name": "user>>>13>>>traffic>>>uplink"},
+		{"name": "user>>>someone@example.com>>>traffic>>>uplink", "value": 1},
+		{"name": "inbound>>>api>>>traffic>>>downlink", "value": 1}
+	]}`))
+	assert.Nil(err)
+	assert.Equal([]Traffic{{SID: 12, Upload: 100, Download: 200}}, traffic)
+
+	traffic, err = parseStats(nil)
+	assert.Nil(err)
+	assert.Empty(traffic)
+
+	_, err = parseStats([]byte("not json"))
+	assert.NotNil(err)
+}
+
+// TestAgent runs the agent against an in-process panel
+func TestAgent(t *testing.T) {
+	assert := assertlib.New(t)
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	node := models.Node{
+		Name:        gofakeit.Word(),
+		Host:        gofakeit.DomainName(),
+		AccessToken: utils.RandString(64),
+	}
+	node.Port = 443
+	orm.DB.Create(&node)
+	casbin.AddNodePolicy(&node)
+
+	var user models.User
+	gofakeit.Struct(&user)
+	user.CurrentTraffic = 0
+	user.MaxTraffic = 0
+	orm.DB.Create(&user)
+
+	service := models.Service{
+		Name:   gofakeit.Word(),
+		UserID: user.ID,
+		NodeID: node.ID,
+	}
+	service.VmessUser.UUID = gofakeit.UUID()
+	orm.DB.Create(&service)
+
+	panel := httptest.NewServer(testutils.GetRouter())
+	defer panel.Close()
+
+	dir, err := ioutil.TempDir("", "raydash-agent")
+	assert.Nil(err)
+	defer os.RemoveAll(dir)
+	reloaded := filepath.Join(dir, "reloaded")
+	statsFile := filepath.Join(dir, "stats.json")
+
+	agent := NewAgent(panel.URL+"/", node.ID, node.AccessToken)
+	agent.Format = "xray"
+	agent.Config = filepath.Join(dir, "config.json")
+	agent.Reload = "touch " + reloaded
+	agent.Stats = &FileStats{Path: statsFile}
+
+	sid := strconv.FormatUint(service.ID, 10)
+	stats := `{"stat": [
+		{"name": "user>>>` + sid + `>>>traffic>>>uplink", "value": "100"},
+		{"name": "user>>>` + sid + `>>>traffic>>>downlink", "value": "200"}
+	]}`
+	assert.Nil(ioutil.WriteFile(statsFile, []byte(stats), 0644))
+
+	assert.Nil(agent.RunOnce())
+
+	config, err := ioutil.ReadFile(agent.Config)
+	assert.Nil(err)
+	assert.Contains(string(config), service.VmessUser.UUID)
+	assert.FileExists(reloaded)
+
+	orm.DB.First(&user, user.ID)
+	assert.Equal(int64(300), user.CurrentTraffic)
+	orm.DB.First(&node, node.ID)
+	assert.True(node.Online)
+	assert.Equal(uint(1), node.OnlineUsers)
+	assert.Equal(Version, node.AgentVersion)
+
+	// Nothing changed, so no reload and no traffic
+	assert.Nil(os.Remove(reloaded))
+	assert.Nil(agent.RunOnce())
+	_, err = os.Stat(reloaded)
+	assert.True(os.IsNotExist(err))
+	orm.DB.First(&user, user.ID)
+	assert.Equal(int64(300), user.CurrentTraffic)
+
+	// A new service changes config
+	other := models.Service{
+		Name:   gofakeit.Word(),
+		UserID: user.ID,
+		NodeID: node.ID,
+	}
+	other.VmessUser.UUID = gofakeit.UUID()
+	orm.DB.Create(&other)
+	assert.Nil(agent.RunOnce())
+	assert.FileExists(reloaded)
+	config, _ = ioutil.ReadFile(agent.Config)
+	assert.Contains(string(config), other.VmessUser.UUID)
+
+	// Traffic of a removed service does not hold back the rest
+	orm.DB.Delete(&other)
+	stats = `{"stat": [
+		{"name": "user>>>` + sid + `>>>traffic>>>uplink", "value": "10"},
+		{"name": "user>>>` + strconv.FormatUint(other.ID, 10) + `>>>traffic>>>uplink", "value": "10"}
+	]}`
+	assert.Nil(ioutil.WriteFile(statsFile, []byte(stats), 0644))
+	assert.Nil(agent.RunOnce())
+	orm.DB.First(&user, user.ID)
+	assert.Equal(int64(310), user.CurrentTraffic)
+	assert.Empty(agent.pending)
+}
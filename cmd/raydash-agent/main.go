@@ -0,0 +1,62 @@
+// Command raydash-agent runs beside v2ray/xray on a node. It pulls config of
+// the node from the panel, reloads v2ray when it changes, reports traffic and
+// sends heartbeats, all with the node access token.
+package main
+
+import (
+	"flag"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/coolray-dev/raydash/modules/log"
+)
+
+func main() {
+	panel := flag.String("panel", "", "base url of the panel")
+	nid := flag.Uint64("node", 0, "node id")
+	token := flag.String("token", os.Getenv("RAYDASH_NODE_TOKEN"), "node access token, defaults to $RAYDASH_NODE_TOKEN")
+	format := flag.String("format", "v2ray", "config format, v2ray or xray")
+	config := flag.String("config", "/etc/v2ray/config.json", "path to write config to")
+	reload := flag.String("reload", "systemctl restart v2ray", "command to run after config changed, empty to skip")
+	stats := flag.String("stats", "v2ray api stats -server=127.0.0.1:10085 -json -reset", "command printing stats in json and resetting them")
+	statsFile := flag.String("stats-file", "", "read stats from this file instead of running the stats command")
+	interval := flag.Duration("interval", time.Minute, "interval between syncs")
+	flag.Parse()
+
+	if *panel == "" || *nid == 0 || *token == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	agent := NewAgent(*panel, *nid, *token)
+	agent.Format = *format
+	agent.Config = *config
+	agent.Reload = *reload
+	if *statsFile != "" {
+		agent.Stats = &FileStats{Path: *statsFile}
+	} else if *stats != "" {
+		agent.Stats = &CommandStats{Command: *stats}
+	}
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	ticker := time.NewTicker(*interval)
+	defer ticker.Stop()
+
+	log.Log.WithField("panel", agent.Panel).WithField("nodeID", agent.NodeID).Info("Agent Started")
+	agent.RunOnce()
+	for {
+		select {
+		case <-signals:
+			// Report what is left before leaving
+			agent.ReportTraffic()
+			log.Log.Info("Agent Stopped")
+			return
+		case <-ticker.C:
+			agent.RunOnce()
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// Traffic is the traffic of a service since last collect
+type Traffic struct {
+	SID      uint64 `json:"sid"`
+	Upload   uint64 `json:"upload"`
+	Download uint64 `json:"download"`
+}
+
+// StatsSource gives traffic deltas of services, every call to Collect
+// returns traffic since the previous call
+type StatsSource interface {
+	Collect() ([]Traffic, error)
+}
+
+// CommandStats runs a stats query command of v2ray/xray which resets counters,
+// such as `xray api statsquery --server=127.0.0.1:10085 -reset`
+type CommandStats struct {
+	Command string
+}
+
+// Collect implements StatsSource
+func (s *CommandStats) Collect() ([]Traffic, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("sh", "-c", s.Command)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("Error running stats command: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return parseStats(out)
+}
+
+// FileStats reads stats in the same format as the stats api from a file and
+// removes it, so whatever writes the file is the counter. It is meant for tests
+// and for setups where stats are fetched by other tools.
+type FileStats struct {
+	Path string
+}
+
+// Collect implements StatsSource
+func (s *FileStats) Collect() ([]Traffic, error) {
+	data, err := ioutil.ReadFile(s.Path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := os.Remove(s.Path); err != nil {
+		return nil, err
+	}
+	return parseStats(data)
+}
+
+type statsResponse struct {
+	Stat []struct {
+		Name  string          `json:"name"`
+		Value json.RawMessage `json:"value"` // int64 may be quoted in protobuf json
+	} `json:"stat"`
+}
+
+// parseStats picks user traffic out of the json output of stats query.
+// Names look like user>>>12>>>traffic>>>uplink where 12 is the service id
+// used as client email in the config built by the panel.
+func parseStats(data []byte) ([]Traffic, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return nil, nil
+	}
+	var resp statsResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		return nil, fmt.Errorf("Error parsing stats: %v", err)
+	}
+
+	var traffic []Traffic
+	index := make(map[uint64]int)
+	for _, stat := range resp.Stat {
+		parts := strings.Split(stat.Name, ">>>")
+		if len(parts) != 4 || parts[0] != "user" || parts[2] != "traffic" {
+			continue
+		}
+		sid, err := strconv.ParseUint(parts[1], 10, 64)
+		if err != nil {
+			// Not a client of the panel
+			continue
+		}
+		// Zero values are left out by protobuf json
+		raw := strings.Trim(string(stat.Value), `"`)
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid value of stat %s: %v", stat.Name, err)
+		}
+		if value == 0 {
+			continue
+		}
+
+		i, ok := index[sid]
+		if !ok {
+			i = len(traffic)
+			index[sid] = i
+			traffic = append(traffic, Traffic{SID: sid})
+		}
+		switch parts[3] {
+		case "uplink":
+			traffic[i].Upload += value
+		case "downlink":
+			traffic[i].Download += value
+		}
+	}
+	return traffic, nil
+}
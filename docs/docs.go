@@ -594,14 +594,14 @@ var doc = `{
                 }
             }
         },
-        "/groups/{gid}/users": {
+        "/groups/{gid}/nodes": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Simply list out all users belong to a certain group",
+                "description": "List out all nodes assigned to a group",
                 "consumes": [
                     "application/json"
                 ],
@@ -611,8 +611,8 @@ var doc = `{
                 "tags": [
                     "Groups"
                 ],
-                "summary": "Get Group Users",
-                "operationId": "groups.Users",
+                "summary": "Get Group Nodes",
+                "operationId": "groups.Nodes",
                 "parameters": [
                     {
                         "type": "integer",
@@ -633,7 +633,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/groups.usersResponse"
+                            "$ref": "#/definitions/groups.nodesResponse"
                         }
                     },
                     "403": {
@@ -642,6 +642,12 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -650,13 +656,13 @@ var doc = `{
                     }
                 }
             },
-            "patch": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Add a user to a group",
+                "description": "Replace nodes assigned to a group",
                 "consumes": [
                     "application/json"
                 ],
@@ -666,18 +672,91 @@ var doc = `{
                 "tags": [
                     "Groups"
                 ],
-                "summary": "Append User",
-                "operationId": "groups.AppendUser",
+                "summary": "Set Group Nodes",
+                "operationId": "groups.SetNodes",
                 "parameters": [
                     {
-                        "description": "Username",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "gid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Node IDs",
+                        "name": "nodes",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/groups.userModificationRequest"
+                            "$ref": "#/definitions/groups.nodesRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/groups.nodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Assign nodes to a group",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Groups"
+                ],
+                "summary": "Append Group Nodes",
+                "operationId": "groups.AppendNodes",
+                "parameters": [
                     {
                         "type": "integer",
                         "description": "Group ID",
@@ -685,6 +764,15 @@ var doc = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "Node IDs",
+                        "name": "nodes",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/groups.nodesRequest"
+                        }
+                    },
                     {
                         "type": "string",
                         "description": "Access Token",
@@ -697,7 +785,13 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/groups.usersResponse"
+                            "$ref": "#/definitions/groups.nodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -706,6 +800,18 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -715,14 +821,14 @@ var doc = `{
                 }
             }
         },
-        "/groups/{gid}/users/{username}": {
+        "/groups/{gid}/nodes/{nid}": {
             "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Remove a user from a group",
+                "description": "Unassign a node from a group",
                 "consumes": [
                     "application/json"
                 ],
@@ -732,8 +838,8 @@ var doc = `{
                 "tags": [
                     "Groups"
                 ],
-                "summary": "Remove User",
-                "operationId": "groups.RemoveUser",
+                "summary": "Remove Group Node",
+                "operationId": "groups.RemoveNode",
                 "parameters": [
                     {
                         "type": "integer",
@@ -743,9 +849,9 @@ var doc = `{
                         "required": true
                     },
                     {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
                         "in": "path",
                         "required": true
                     },
@@ -761,7 +867,13 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/groups.usersResponse"
+                            "$ref": "#/definitions/groups.nodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -770,6 +882,12 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -779,14 +897,14 @@ var doc = `{
                 }
             }
         },
-        "/nodes": {
+        "/groups/{gid}/users": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Simply list out all Nodes",
+                "description": "Simply list out all users belong to a certain group",
                 "consumes": [
                     "application/json"
                 ],
@@ -794,11 +912,18 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Nodes"
+                    "Groups"
                 ],
-                "summary": "All Nodes",
-                "operationId": "Nodes.Index",
+                "summary": "Get Group Users",
+                "operationId": "groups.Users",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "gid",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Access Token",
@@ -811,7 +936,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.indexResponse"
+                            "$ref": "#/definitions/groups.usersResponse"
                         }
                     },
                     "403": {
@@ -828,13 +953,13 @@ var doc = `{
                     }
                 }
             },
-            "post": {
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create node from post json object",
+                "description": "Add a user to a group",
                 "consumes": [
                     "application/json"
                 ],
@@ -842,20 +967,27 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Nodes"
+                    "Groups"
                 ],
-                "summary": "Create Node",
-                "operationId": "Nodes.Create",
+                "summary": "Append User",
+                "operationId": "groups.AppendUser",
                 "parameters": [
                     {
-                        "description": "Node Object",
-                        "name": "node",
+                        "description": "Username",
+                        "name": "user",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Node"
+                            "$ref": "#/definitions/groups.userModificationRequest"
                         }
                     },
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "gid",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Access Token",
@@ -868,7 +1000,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.createResponse"
+                            "$ref": "#/definitions/groups.usersResponse"
                         }
                     },
                     "403": {
@@ -877,6 +1009,12 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -886,14 +1024,14 @@ var doc = `{
                 }
             }
         },
-        "/nodes/{nid}": {
-            "get": {
+        "/groups/{gid}/users/{username}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Show Node according to nid",
+                "description": "Remove a user from a group",
                 "consumes": [
                     "application/json"
                 ],
@@ -901,15 +1039,22 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Nodes"
+                    "Groups"
                 ],
-                "summary": "Show Node",
-                "operationId": "Nodes.Show",
+                "summary": "Remove User",
+                "operationId": "groups.RemoveUser",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Node ID",
-                        "name": "nid",
+                        "description": "Group ID",
+                        "name": "gid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
                         "in": "path",
                         "required": true
                     },
@@ -925,7 +1070,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.showResponse"
+                            "$ref": "#/definitions/groups.usersResponse"
                         }
                     },
                     "403": {
@@ -941,14 +1086,16 @@ var doc = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/nodes": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Destroy Node according to nid",
+                "description": "Simply list out all Nodes",
                 "consumes": [
                     "application/json"
                 ],
@@ -958,15 +1105,20 @@ var doc = `{
                 "tags": [
                     "Nodes"
                 ],
-                "summary": "Destroy Node",
-                "operationId": "Nodes.Destroy",
+                "summary": "All Nodes",
+                "operationId": "Nodes.Index",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Node ID",
-                        "name": "nid",
-                        "in": "path",
-                        "required": true
+                        "description": "Group ID",
+                        "name": "gid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, nodes having any of them are listed",
+                        "name": "tags",
+                        "in": "query"
                     },
                     {
                         "type": "string",
@@ -980,7 +1132,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.destroyResponse"
+                            "$ref": "#/definitions/nodes.indexResponse"
                         }
                     },
                     "403": {
@@ -997,13 +1149,13 @@ var doc = `{
                     }
                 }
             },
-            "patch": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update a Node",
+                "description": "Create node from post json object",
                 "consumes": [
                     "application/json"
                 ],
@@ -1013,16 +1165,9 @@ var doc = `{
                 "tags": [
                     "Nodes"
                 ],
-                "summary": "Update Node",
-                "operationId": "Nodes.Update",
+                "summary": "Create Node",
+                "operationId": "Nodes.Create",
                 "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Node ID",
-                        "name": "nid",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "description": "Node Object",
                         "name": "node",
@@ -1044,7 +1189,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.updateResponse"
+                            "$ref": "#/definitions/nodes.createResponse"
                         }
                     },
                     "403": {
@@ -1062,14 +1207,14 @@ var doc = `{
                 }
             }
         },
-        "/nodes/{nid}/services": {
+        "/nodes/{nid}": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Show services of a node",
+                "description": "Show Node according to nid",
                 "consumes": [
                     "application/json"
                 ],
@@ -1079,8 +1224,8 @@ var doc = `{
                 "tags": [
                     "Nodes"
                 ],
-                "summary": "Node Services",
-                "operationId": "Nodes.Services",
+                "summary": "Show Node",
+                "operationId": "Nodes.Show",
                 "parameters": [
                     {
                         "type": "integer",
@@ -1101,7 +1246,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.servicesResponse"
+                            "$ref": "#/definitions/nodes.showResponse"
                         }
                     },
                     "403": {
@@ -1117,16 +1262,14 @@ var doc = `{
                         }
                     }
                 }
-            }
-        },
-        "/nodes/{nid}/token": {
-            "get": {
+            },
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Node AccessToken according to nid",
+                "description": "Destroy Node according to nid",
                 "consumes": [
                     "application/json"
                 ],
@@ -1136,8 +1279,8 @@ var doc = `{
                 "tags": [
                     "Nodes"
                 ],
-                "summary": "Node AccessToken",
-                "operationId": "Nodes.AccessToken",
+                "summary": "Destroy Node",
+                "operationId": "Nodes.Destroy",
                 "parameters": [
                     {
                         "type": "integer",
@@ -1158,7 +1301,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.accessTokenResponse"
+                            "$ref": "#/definitions/nodes.destroyResponse"
                         }
                     },
                     "403": {
@@ -1175,15 +1318,15 @@ var doc = `{
                     }
                 }
             },
-            "post": {
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Generate Node AccessToken according to nid",
+                "description": "Update a Node",
                 "consumes": [
-                    "text/plain"
+                    "application/json"
                 ],
                 "produces": [
                     "application/json"
@@ -1191,8 +1334,8 @@ var doc = `{
                 "tags": [
                     "Nodes"
                 ],
-                "summary": "Generate Node AccessToken",
-                "operationId": "Nodes.GenerateToken",
+                "summary": "Update Node",
+                "operationId": "Nodes.Update",
                 "parameters": [
                     {
                         "type": "integer",
@@ -1201,6 +1344,15 @@ var doc = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "description": "Node Object",
+                        "name": "node",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Node"
+                        }
+                    },
                     {
                         "type": "string",
                         "description": "Access Token",
@@ -1213,7 +1365,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.accessTokenResponse"
+                            "$ref": "#/definitions/nodes.updateResponse"
                         }
                     },
                     "403": {
@@ -1231,25 +1383,22 @@ var doc = `{
                 }
             }
         },
-        "/nodes/{nid}/users": {
+        "/nodes/{nid}/config": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Show Users of a node",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Render v2ray/xray config of a node, an ETag is returned so agents could poll with If-None-Match",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
                     "Nodes"
                 ],
-                "summary": "Node Users",
-                "operationId": "Nodes.Users",
+                "summary": "Node Config",
+                "operationId": "Nodes.Config",
                 "parameters": [
                     {
                         "type": "integer",
@@ -1258,6 +1407,18 @@ var doc = `{
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "string",
+                        "description": "v2ray (default) or xray",
+                        "name": "format",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "ETag of the config agent has",
+                        "name": "If-None-Match",
+                        "in": "header"
+                    },
                     {
                         "type": "string",
                         "description": "Access Token",
@@ -1270,7 +1431,19 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.usersResponse"
+                            "$ref": "#/definitions/v2ray.Config"
+                        }
+                    },
+                    "304": {
+                        "description": "Not Modified",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -1279,6 +1452,12 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1288,14 +1467,14 @@ var doc = `{
                 }
             }
         },
-        "/nodes/{nid}/users/{username}/traffic": {
-            "patch": {
+        "/nodes/{nid}/groups": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update user traffic",
+                "description": "List out all groups a node is assigned to",
                 "consumes": [
                     "application/json"
                 ],
@@ -1305,18 +1484,9 @@ var doc = `{
                 "tags": [
                     "Nodes"
                 ],
-                "summary": "Update user traffic",
-                "operationId": "nodes.Traffic",
+                "summary": "Get Node Groups",
+                "operationId": "Nodes.Groups",
                 "parameters": [
-                    {
-                        "description": "Traffic Info Object",
-                        "name": "traffic",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/nodes.trafficRequest"
-                        }
-                    },
                     {
                         "type": "integer",
                         "description": "Node ID",
@@ -1326,23 +1496,29 @@ var doc = `{
                     },
                     {
                         "type": "string",
-                        "description": "Username",
-                        "name": "username",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "string",
-                        "description": "Node Token",
+                        "description": "Access Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/nodes.trafficResponse"
+                            "$ref": "#/definitions/nodes.groupsResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1352,16 +1528,14 @@ var doc = `{
                         }
                     }
                 }
-            }
-        },
-        "/services": {
-            "get": {
+            },
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Simply list out all Services",
+                "description": "Replace groups a node is assigned to",
                 "consumes": [
                     "application/json"
                 ],
@@ -1369,11 +1543,27 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Services"
+                    "Nodes"
                 ],
-                "summary": "All Services",
-                "operationId": "Services.Index",
+                "summary": "Set Node Groups",
+                "operationId": "Nodes.SetGroups",
                 "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Group IDs",
+                        "name": "groups",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/nodes.groupsRequest"
+                        }
+                    },
                     {
                         "type": "string",
                         "description": "Access Token",
@@ -1386,7 +1576,13 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.indexResponse"
+                            "$ref": "#/definitions/nodes.groupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -1395,6 +1591,18 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1403,13 +1611,13 @@ var doc = `{
                     }
                 }
             },
-            "post": {
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Create a service from post json object",
+                "description": "Assign a node to groups",
                 "consumes": [
                     "application/json"
                 ],
@@ -1417,18 +1625,25 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Services"
+                    "Nodes"
                 ],
-                "summary": "Create Service",
-                "operationId": "Services.Store",
+                "summary": "Append Node Groups",
+                "operationId": "Nodes.AppendGroups",
                 "parameters": [
                     {
-                        "description": "Service Object",
-                        "name": "service",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Group IDs",
+                        "name": "groups",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/services.storeRequest"
+                            "$ref": "#/definitions/nodes.groupsRequest"
                         }
                     },
                     {
@@ -1443,7 +1658,13 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.serviceResponse"
+                            "$ref": "#/definitions/nodes.groupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -1452,6 +1673,18 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1461,14 +1694,14 @@ var doc = `{
                 }
             }
         },
-        "/services/{nid}": {
-            "get": {
+        "/nodes/{nid}/groups/{gid}": {
+            "delete": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Show a service according to nid",
+                "description": "Unassign a node from a group",
                 "consumes": [
                     "application/json"
                 ],
@@ -1476,18 +1709,25 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Services"
+                    "Nodes"
                 ],
-                "summary": "Show Service",
-                "operationId": "Services.Show",
+                "summary": "Remove Node Group",
+                "operationId": "Nodes.RemoveGroup",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Services ID",
+                        "description": "Node ID",
                         "name": "nid",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "gid",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Access Token",
@@ -1500,7 +1740,13 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.serviceResponse"
+                            "$ref": "#/definitions/nodes.groupsResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -1509,6 +1755,12 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1516,14 +1768,16 @@ var doc = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/nodes/{nid}/heartbeat": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update a Service",
+                "description": "Record uptime, load, online users and agent version of a node and mark it online",
                 "consumes": [
                     "application/json"
                 ],
@@ -1531,30 +1785,30 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Services"
+                    "Nodes"
                 ],
-                "summary": "Update Service",
-                "operationId": "Services.Update",
+                "summary": "Node heartbeat",
+                "operationId": "Nodes.Heartbeat",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Service ID",
-                        "name": "sid",
+                        "description": "Node ID",
+                        "name": "nid",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Service Object",
-                        "name": "service",
+                        "description": "Node Status",
+                        "name": "heartbeat",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/models.Service"
+                            "$ref": "#/definitions/nodes.heartbeatRequest"
                         }
                     },
                     {
                         "type": "string",
-                        "description": "Access Token",
+                        "description": "Node Token",
                         "name": "Authorization",
                         "in": "header",
                         "required": true
@@ -1564,7 +1818,13 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.serviceResponse"
+                            "$ref": "#/definitions/nodes.heartbeatResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -1573,6 +1833,12 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1582,14 +1848,14 @@ var doc = `{
                 }
             }
         },
-        "/services/{sid}": {
-            "delete": {
+        "/nodes/{nid}/services": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Destroy a service according to sid",
+                "description": "Show services of a node",
                 "consumes": [
                     "application/json"
                 ],
@@ -1597,15 +1863,15 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Services"
+                    "Nodes"
                 ],
-                "summary": "Destroy Service",
-                "operationId": "Services.Destroy",
+                "summary": "Node Services",
+                "operationId": "Nodes.Services",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Service ID",
-                        "name": "sid",
+                        "description": "Node ID",
+                        "name": "nid",
                         "in": "path",
                         "required": true
                     },
@@ -1621,7 +1887,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/services.destroyResponse"
+                            "$ref": "#/definitions/nodes.servicesResponse"
                         }
                     },
                     "403": {
@@ -1639,14 +1905,14 @@ var doc = `{
                 }
             }
         },
-        "/users": {
-            "get": {
+        "/nodes/{nid}/state": {
+            "put": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Simply list out all users",
+                "description": "Set node active, in maintenance or disabled, nodes not active are hidden from users but keep services",
                 "consumes": [
                     "application/json"
                 ],
@@ -1654,29 +1920,46 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Nodes"
                 ],
-                "summary": "All Users",
-                "operationId": "users.Index",
+                "summary": "Set Node State",
+                "operationId": "Nodes.State",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Group ID",
-                        "name": "gid",
-                        "in": "query"
+                        "description": "Node ID",
+                        "name": "nid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Node State",
+                        "name": "state",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/nodes.stateRequest"
+                        }
                     },
                     {
                         "type": "string",
-                        "description": "Node Token",
+                        "description": "Access Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.indexResponse"
+                            "$ref": "#/definitions/nodes.stateResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "403": {
@@ -1685,8 +1968,14 @@ var doc = `{
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
                         "schema": {
                             "$ref": "#/definitions/handler.ErrorResponse"
                         }
@@ -1694,14 +1983,14 @@ var doc = `{
                 }
             }
         },
-        "/users/{username}": {
+        "/nodes/{nid}/token": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Return user according to username in url",
+                "description": "Node AccessToken according to nid",
                 "consumes": [
                     "application/json"
                 ],
@@ -1709,30 +1998,37 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Nodes"
                 ],
-                "summary": "Show the required user",
-                "operationId": "users.Show",
+                "summary": "Node AccessToken",
+                "operationId": "Nodes.AccessToken",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Node Token",
+                        "description": "Access Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.showResponse"
+                            "$ref": "#/definitions/nodes.accessTokenResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1743,44 +2039,45 @@ var doc = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Delete a user according to username",
+                "description": "Generate Node AccessToken according to nid",
                 "consumes": [
-                    "application/json"
+                    "text/plain"
                 ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Nodes"
                 ],
-                "summary": "Delete a user",
-                "operationId": "users.Destroy",
+                "summary": "Generate Node AccessToken",
+                "operationId": "Nodes.GenerateToken",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Node Token",
+                        "description": "Access Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.destroyResponse"
+                            "$ref": "#/definitions/nodes.accessTokenResponse"
                         }
                     },
                     "403": {
@@ -1796,14 +2093,16 @@ var doc = `{
                         }
                     }
                 }
-            },
-            "patch": {
+            }
+        },
+        "/nodes/{nid}/traffic": {
+            "post": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update the user provided",
+                "description": "Add a batch of per-service upload/download deltas to users and node atomically, skipping and returning unknown services",
                 "consumes": [
                     "application/json"
                 ],
@@ -1811,39 +2110,58 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Nodes"
                 ],
-                "summary": "Update user",
-                "operationId": "users.Update",
+                "summary": "Report node traffic",
+                "operationId": "Nodes.Report",
                 "parameters": [
                     {
-                        "description": "User Object",
-                        "name": "user",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Traffic Deltas",
+                        "name": "traffic",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/users.updateRequest"
+                            "$ref": "#/definitions/nodes.reportRequest"
                         }
                     },
-                    {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
                         "description": "Node Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
-                            "$ref": "#/definitions/users.updateResponse"
+                            "$ref": "#/definitions/nodes.reportResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1855,45 +2173,85 @@ var doc = `{
                 }
             }
         },
-        "/users/{username}/groups": {
+        "/nodes/{nid}/traffic/history": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Return a list of groups of a user",
-                "consumes": [
-                    "application/json"
-                ],
+                "description": "Traffic of a node between from and to by interval, optionally of a single user",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Nodes"
                 ],
-                "summary": "List all groups",
-                "operationId": "users.Groups",
+                "summary": "Node traffic history",
+                "operationId": "Nodes.History",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
                         "in": "path",
                         "required": true
                     },
+                    {
+                        "type": "integer",
+                        "description": "Unix timestamp, defaults to a day or 30 days before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix timestamp, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
                     {
                         "type": "string",
-                        "description": "Node Token",
+                        "description": "hour (default) or day",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "User ID",
+                        "name": "uid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.groupsResponse"
+                            "$ref": "#/definitions/nodes.historyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1905,14 +2263,14 @@ var doc = `{
                 }
             }
         },
-        "/users/{username}/nodes": {
+        "/nodes/{nid}/users": {
             "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Return a list of nodes of a user",
+                "description": "Show Users of a node",
                 "consumes": [
                     "application/json"
                 ],
@@ -1920,30 +2278,37 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Nodes"
                 ],
-                "summary": "List all nodes",
-                "operationId": "users.Nodes",
+                "summary": "Node Users",
+                "operationId": "Nodes.Users",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
                         "in": "path",
                         "required": true
                     },
                     {
                         "type": "string",
-                        "description": "Node Token",
+                        "description": "Access Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.nodesResponse"
+                            "$ref": "#/definitions/nodes.usersResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
@@ -1955,14 +2320,14 @@ var doc = `{
                 }
             }
         },
-        "/users/{username}/services": {
-            "get": {
+        "/nodes/{nid}/users/{username}/traffic": {
+            "patch": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Return a list of services of a user",
+                "description": "Update user traffic",
                 "consumes": [
                     "application/json"
                 ],
@@ -1970,11 +2335,27 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Nodes"
                 ],
-                "summary": "List all services",
-                "operationId": "users.Services",
+                "summary": "Update user traffic",
+                "operationId": "nodes.Traffic",
                 "parameters": [
+                    {
+                        "description": "Traffic Info Object",
+                        "name": "traffic",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/nodes.trafficRequest"
+                        }
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
+                        "in": "path",
+                        "required": true
+                    },
                     {
                         "type": "string",
                         "description": "Username",
@@ -1993,7 +2374,7 @@ var doc = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.servicesResponse"
+                            "$ref": "#/definitions/nodes.trafficResponse"
                         }
                     },
                     "500": {
@@ -2005,14 +2386,14 @@ var doc = `{
                 }
             }
         },
-        "/users/{username}/traffic": {
-            "patch": {
+        "/profiles": {
+            "get": {
                 "security": [
                     {
                         "ApiKeyAuth": []
                     }
                 ],
-                "description": "Update user traffic",
+                "description": "Simply list out all clash profiles",
                 "consumes": [
                     "application/json"
                 ],
@@ -2020,39 +2401,30 @@ var doc = `{
                     "application/json"
                 ],
                 "tags": [
-                    "Users"
+                    "Profiles"
                 ],
-                "summary": "User traffic",
-                "operationId": "users.Traffic",
+                "summary": "All Profiles",
+                "operationId": "Profiles.Index",
                 "parameters": [
-                    {
-                        "description": "User Object",
-                        "name": "user",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/users.trafficRequest"
-                        }
-                    },
-                    {
-                        "type": "string",
-                        "description": "Username",
-                        "name": "username",
-                        "in": "path",
-                        "required": true
-                    },
                     {
                         "type": "string",
-                        "description": "Node Token",
+                        "description": "Access Token",
                         "name": "Authorization",
-                        "in": "header"
+                        "in": "header",
+                        "required": true
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/users.userResponse"
+                            "$ref": "#/definitions/profiles.indexResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
                         }
                     },
                     "500": {
@@ -2062,53 +2434,2578 @@ var doc = `{
                         }
                     }
                 }
-            }
-        }
-    },
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a clash profile holding dns servers, proxy groups and rules",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Profiles"
+                ],
+                "summary": "Create Profile",
+                "operationId": "Profiles.Create",
+                "parameters": [
+                    {
+                        "description": "Profile Object",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Profile"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "$ref": "#/definitions/profiles.profileResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/profiles/{pid}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Show clash profile according to pid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Profiles"
+                ],
+                "summary": "Show Profile",
+                "operationId": "Profiles.Show",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Profile ID",
+                        "name": "pid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/profiles.profileResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Destroy clash profile according to pid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Profiles"
+                ],
+                "summary": "Destroy Profile",
+                "operationId": "Profiles.Destroy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Profile ID",
+                        "name": "pid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/profiles.destroyResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a clash profile",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Profiles"
+                ],
+                "summary": "Update Profile",
+                "operationId": "Profiles.Update",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Profile ID",
+                        "name": "pid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Profile Object",
+                        "name": "profile",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Profile"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/profiles.profileResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Simply list out all Services",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "All Services",
+                "operationId": "Services.Index",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.indexResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Create a service from post json object",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Create Service",
+                "operationId": "Services.Store",
+                "parameters": [
+                    {
+                        "description": "Service Object",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/services.storeRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.serviceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{nid}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Show a service according to nid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Show Service",
+                "operationId": "Services.Show",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Services ID",
+                        "name": "nid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.serviceResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update a Service",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Update Service",
+                "operationId": "Services.Update",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Service Object",
+                        "name": "service",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/models.Service"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.serviceResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/services/{sid}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Destroy a service according to sid",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Services"
+                ],
+                "summary": "Destroy Service",
+                "operationId": "Services.Destroy",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Service ID",
+                        "name": "sid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/services.destroyResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/subscription/{format}": {
+            "get": {
+                "description": "Render subscription of the token owner, format could be clash, singbox or v2rayn",
+                "produces": [
+                    "text/plain"
+                ],
+                "tags": [
+                    "Subscription"
+                ],
+                "summary": "Subscription",
+                "operationId": "Subscription.Render",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Subscription Format",
+                        "name": "format",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Subscription Token",
+                        "name": "token",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Comma separated tags, only nodes having any of them are included",
+                        "name": "tags",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "string"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Simply list out all users",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "All Users",
+                "operationId": "users.Index",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Group ID",
+                        "name": "gid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.indexResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return user according to username in url",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Show the required user",
+                "operationId": "users.Show",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.showResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Delete a user according to username",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Delete a user",
+                "operationId": "users.Destroy",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.destroyResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update the user provided",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Update user",
+                "operationId": "users.Update",
+                "parameters": [
+                    {
+                        "description": "User Object",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.updateRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.updateResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/groups": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return a list of groups of a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List all groups",
+                "operationId": "users.Groups",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.groupsResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/lock": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Clear failed login attempts of a user so the user could login right away",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Unlock User",
+                "operationId": "users.Unlock",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/nodes": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return a list of nodes of a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List all nodes",
+                "operationId": "users.Nodes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.nodesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/password": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Change password with the current one, every session is revoked",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Change Password",
+                "operationId": "users.Password",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Current and New Password",
+                        "name": "password",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.passwordRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/services": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return a list of services of a user",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List all services",
+                "operationId": "users.Services",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.servicesResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/sessions": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Return unexpired sessions of a user, most recently used first",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "List Sessions",
+                "operationId": "users.Sessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.sessionsResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke every session of a user, access tokens already issued last until they expire",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Revoke All Sessions",
+                "operationId": "users.RevokeSessions",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/sessions/{sessid}": {
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Revoke a session so its refresh token stops working",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Revoke Session",
+                "operationId": "users.RevokeSession",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Session ID",
+                        "name": "sessid",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/totp": {
+            "put": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Enable two-factor authentication with the first code of the enrolled secret and return recovery codes",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Confirm TOTP",
+                "operationId": "users.ConfirmTOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "TOTP Code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.totpRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.recoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Generate a TOTP secret pending confirmation",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Enroll TOTP",
+                "operationId": "users.EnrollTOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.enrollTOTPResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Disable two-factor authentication, not allowed when a group of the user requires it",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Disable TOTP",
+                "operationId": "users.DisableTOTP",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "TOTP or Recovery Code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.totpRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "204": {},
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/totp/recovery": {
+            "post": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Replace all recovery codes, old ones stop working",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "Regenerate Recovery Codes",
+                "operationId": "users.RecoveryCodes",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "TOTP or Recovery Code",
+                        "name": "code",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.totpRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.recoveryCodesResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/traffic": {
+            "patch": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Update user traffic",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "User traffic",
+                "operationId": "users.Traffic",
+                "parameters": [
+                    {
+                        "description": "User Object",
+                        "name": "user",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/users.trafficRequest"
+                        }
+                    },
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Node Token",
+                        "name": "Authorization",
+                        "in": "header"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.userResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        },
+        "/users/{username}/traffic/history": {
+            "get": {
+                "security": [
+                    {
+                        "ApiKeyAuth": []
+                    }
+                ],
+                "description": "Traffic of a user between from and to by interval, optionally on a single node",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "Users"
+                ],
+                "summary": "User traffic history",
+                "operationId": "users.History",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "Username",
+                        "name": "username",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix timestamp, defaults to a day or 30 days before to",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Unix timestamp, defaults to now",
+                        "name": "to",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "hour (default) or day",
+                        "name": "interval",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Node ID",
+                        "name": "nid",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Access Token",
+                        "name": "Authorization",
+                        "in": "header",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/users.historyResponse"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "403": {
+                        "description": "Forbidden",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "$ref": "#/definitions/handler.ErrorResponse"
+                        }
+                    }
+                }
+            }
+        }
+    },
     "definitions": {
         "announcements.annRequest": {
             "type": "object",
-            "required": [
-                "content",
-                "level",
-                "title"
-            ],
+            "required": [
+                "content",
+                "level",
+                "title"
+            ],
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "level": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                }
+            }
+        },
+        "announcements.annResponse": {
+            "type": "object",
+            "properties": {
+                "announcement": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Announcement"
+                }
+            }
+        },
+        "announcements.destroyResponse": {
+            "type": "object",
+            "properties": {
+                "announcement": {
+                    "type": "string"
+                }
+            }
+        },
+        "announcements.indexResponse": {
+            "type": "object",
+            "properties": {
+                "announcements": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Announcement"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "groups.createResponse": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Group"
+                }
+            }
+        },
+        "groups.destroyResponse": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "string"
+                }
+            }
+        },
+        "groups.indexResponse": {
+            "type": "object",
+            "properties": {
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Group"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "groups.nodesRequest": {
+            "type": "object",
+            "required": [
+                "nids"
+            ],
+            "properties": {
+                "nids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "groups.nodesResponse": {
+            "type": "object",
+            "properties": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Node"
+                    }
+                }
+            }
+        },
+        "groups.showResponse": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Group"
+                }
+            }
+        },
+        "groups.updateResponse": {
+            "type": "object",
+            "properties": {
+                "group": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Group"
+                }
+            }
+        },
+        "groups.userModificationRequest": {
+            "type": "object",
+            "required": [
+                "username"
+            ],
+            "properties": {
+                "username": {
+                    "type": "string"
+                }
+            }
+        },
+        "groups.usersResponse": {
+            "type": "object",
+            "properties": {
+                "user": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                }
+            }
+        },
+        "handler.ErrorResponse": {
+            "type": "object",
+            "properties": {
+                "error": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Announcement": {
+            "type": "object",
+            "properties": {
+                "content": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "level": {
+                    "type": "string"
+                },
+                "title": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Group": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "groupname": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "profile_id": {
+                    "description": "clash profile of group members",
+                    "type": "integer"
+                },
+                "require_mfa": {
+                    "description": "members must enable two-factor authentication to login",
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Node": {
+            "type": "object",
+            "properties": {
+                "agentVersion": {
+                    "type": "string"
+                },
+                "allowInsecure": {
+                    "description": "skip certificate verification on client side",
+                    "type": "boolean"
+                },
+                "certificateFile": {
+                    "type": "string"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "current_traffic": {
+                    "type": "integer"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "dest": {
+                    "description": "defaults to serverName:443",
+                    "type": "string"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "description": "uTLS client fingerprint, e.g. chrome",
+                    "type": "string"
+                },
+                "flow": {
+                    "description": "flow given to services of the node",
+                    "type": "string"
+                },
+                "grpcServiceName": {
+                    "description": "service name of grpc transport",
+                    "type": "string"
+                },
+                "hasMultiPort": {
+                    "type": "boolean"
+                },
+                "hasUDP": {
+                    "type": "boolean"
+                },
+                "host": {
+                    "description": "The Host to access v2ray",
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "keyFile": {
+                    "type": "string"
+                },
+                "lastSeenAt": {
+                    "type": "string"
+                },
+                "last_reset_at": {
+                    "description": "start of current traffic cycle, nil until the scheduler first runs",
+                    "type": "string"
+                },
+                "listen": {
+                    "type": "string"
+                },
+                "load1": {
+                    "type": "number"
+                },
+                "load15": {
+                    "type": "number"
+                },
+                "load5": {
+                    "type": "number"
+                },
+                "max_traffic": {
+                    "type": "integer"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "multiplier": {
+                    "description": "user quota charged per byte of traffic, 1 when absent",
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "online": {
+                    "description": "computed from LastSeenAt after loading",
+                    "type": "boolean"
+                },
+                "onlineUsers": {
+                    "type": "integer"
+                },
+                "plugin": {
+                    "description": "obfs-local or v2ray-plugin",
+                    "type": "string"
+                },
+                "pluginOpts": {
+                    "description": "SIP003 options, e.g. obfs=http;obfs-host=example.com",
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "ports": {
+                    "description": "ports for services of multi port node, such as 10000-10999,20000",
+                    "type": "string"
+                },
+                "protocol": {
+                    "description": "tcp, ws or grpc",
+                    "type": "string"
+                },
+                "realityPublicKey": {
+                    "type": "string"
+                },
+                "realityShortId": {
+                    "type": "string"
+                },
+                "region": {
+                    "description": "ISO 3166-1 alpha-2 country code such as HK",
+                    "type": "string"
+                },
+                "reset_day": {
+                    "description": "day of month to reset traffic, 0 disables",
+                    "type": "integer"
+                },
+                "security": {
+                    "description": "none, tls or reality",
+                    "type": "string"
+                },
+                "serverName": {
+                    "description": "SNI used in tls and reality handshake",
+                    "type": "string"
+                },
+                "sort": {
+                    "description": "nodes are listed by ascending sort",
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "state": {
+                    "type": "string"
+                },
+                "tags": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uptime": {
+                    "description": "seconds",
+                    "type": "integer"
+                },
+                "wsHost": {
+                    "description": "Host header of websocket transport",
+                    "type": "string"
+                },
+                "wsPath": {
+                    "description": "path of websocket transport",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Profile": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "fallback": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nameservers": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "proxy_groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.ProxyGroup"
+                    }
+                },
+                "rules": {
+                    "description": "e.g. DOMAIN-SUFFIX,google.com,PROXY",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "updated_at": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ProxyGroup": {
+            "type": "object",
+            "properties": {
+                "interval": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "proxies": {
+                    "description": "other groups, DIRECT or REJECT placed before user proxies",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "type": {
+                    "description": "select, url-test, fallback or load-balance",
+                    "type": "string"
+                },
+                "url": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.Service": {
+            "type": "object",
+            "properties": {
+                "allowInsecure": {
+                    "description": "skip certificate verification on client side",
+                    "type": "boolean"
+                },
+                "alterid": {
+                    "type": "integer"
+                },
+                "created_at": {
+                    "type": "string"
+                },
+                "description": {
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "fingerprint": {
+                    "description": "uTLS client fingerprint, e.g. chrome",
+                    "type": "string"
+                },
+                "flow": {
+                    "description": "empty or xtls-rprx-vision",
+                    "type": "string"
+                },
+                "grpcServiceName": {
+                    "description": "service name of grpc transport",
+                    "type": "string"
+                },
+                "host": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "method": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "nid": {
+                    "type": "integer"
+                },
+                "password": {
+                    "type": "string"
+                },
+                "plugin": {
+                    "description": "obfs-local or v2ray-plugin",
+                    "type": "string"
+                },
+                "pluginOpts": {
+                    "description": "SIP003 options, e.g. obfs=http;obfs-host=example.com",
+                    "type": "string"
+                },
+                "port": {
+                    "type": "integer"
+                },
+                "protocol": {
+                    "description": "tcp, ws or grpc",
+                    "type": "string"
+                },
+                "realityPublicKey": {
+                    "type": "string"
+                },
+                "realityShortId": {
+                    "type": "string"
+                },
+                "security": {
+                    "description": "none, tls or reality",
+                    "type": "string"
+                },
+                "serverName": {
+                    "description": "SNI used in tls and reality handshake",
+                    "type": "string"
+                },
+                "uid": {
+                    "type": "integer"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                },
+                "wsHost": {
+                    "description": "Host header of websocket transport",
+                    "type": "string"
+                },
+                "wsPath": {
+                    "description": "path of websocket transport",
+                    "type": "string"
+                }
+            }
+        },
+        "models.Session": {
+            "type": "object",
+            "properties": {
+                "created_at": {
+                    "type": "string"
+                },
+                "expire_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "ip": {
+                    "type": "string"
+                },
+                "last_used_at": {
+                    "type": "string"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "user_agent": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShadowsocksSetting": {
+            "type": "object",
+            "properties": {
+                "method": {
+                    "type": "string"
+                },
+                "plugin": {
+                    "description": "obfs-local or v2ray-plugin",
+                    "type": "string"
+                },
+                "pluginOpts": {
+                    "description": "SIP003 options, e.g. obfs=http;obfs-host=example.com",
+                    "type": "string"
+                }
+            }
+        },
+        "models.ShadowsocksUser": {
+            "type": "object",
+            "properties": {
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.TrafficPoint": {
+            "type": "object",
+            "properties": {
+                "download": {
+                    "type": "integer"
+                },
+                "time": {
+                    "type": "string"
+                },
+                "unknown": {
+                    "type": "integer"
+                },
+                "upload": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrafficReport": {
+            "type": "object",
             "properties": {
-                "content": {
+                "created_at": {
                     "type": "string"
                 },
-                "level": {
+                "download": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "nid": {
+                    "type": "integer"
+                },
+                "services": {
+                    "description": "number of services in the batch",
+                    "type": "integer"
+                },
+                "updated_at": {
                     "type": "string"
                 },
-                "title": {
+                "upload": {
+                    "type": "integer"
+                }
+            }
+        },
+        "models.TrojanUser": {
+            "type": "object",
+            "properties": {
+                "password": {
                     "type": "string"
                 }
             }
         },
-        "announcements.annResponse": {
+        "models.User": {
             "type": "object",
             "properties": {
-                "announcement": {
+                "created_at": {
+                    "type": "string"
+                },
+                "current_traffic": {
+                    "type": "integer"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "expire_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "last_reset_at": {
+                    "description": "start of current traffic cycle, nil until the scheduler first runs",
+                    "type": "string"
+                },
+                "max_traffic": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "subscription_token": {
+                    "type": "string"
+                },
+                "totp_enabled": {
+                    "type": "boolean"
+                },
+                "updated_at": {
+                    "type": "string"
+                },
+                "username": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VlessUser": {
+            "type": "object",
+            "properties": {
+                "flow": {
+                    "description": "empty or xtls-rprx-vision",
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "models.VmessSetting": {
+            "type": "object",
+            "properties": {
+                "allowInsecure": {
+                    "description": "skip certificate verification on client side",
+                    "type": "boolean"
+                },
+                "fingerprint": {
+                    "description": "uTLS client fingerprint, e.g. chrome",
+                    "type": "string"
+                },
+                "grpcServiceName": {
+                    "description": "service name of grpc transport",
+                    "type": "string"
+                },
+                "protocol": {
+                    "description": "tcp, ws or grpc",
+                    "type": "string"
+                },
+                "realityPublicKey": {
+                    "type": "string"
+                },
+                "realityShortId": {
+                    "type": "string"
+                },
+                "security": {
+                    "description": "none, tls or reality",
+                    "type": "string"
+                },
+                "serverName": {
+                    "description": "SNI used in tls and reality handshake",
+                    "type": "string"
+                },
+                "wsHost": {
+                    "description": "Host header of websocket transport",
+                    "type": "string"
+                },
+                "wsPath": {
+                    "description": "path of websocket transport",
+                    "type": "string"
+                }
+            }
+        },
+        "nodes.accessTokenResponse": {
+            "type": "object",
+            "properties": {
+                "access_token": {
+                    "type": "string"
+                }
+            }
+        },
+        "nodes.createResponse": {
+            "type": "object",
+            "properties": {
+                "node": {
                     "type": "object",
-                    "$ref": "#/definitions/models.Announcement"
+                    "$ref": "#/definitions/models.Node"
                 }
             }
         },
-        "announcements.destroyResponse": {
+        "nodes.destroyResponse": {
             "type": "object",
             "properties": {
-                "announcement": {
+                "node": {
                     "type": "string"
                 }
             }
         },
-        "announcements.indexResponse": {
+        "nodes.groupsRequest": {
+            "type": "object",
+            "required": [
+                "gids"
+            ],
+            "properties": {
+                "gids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "nodes.groupsResponse": {
             "type": "object",
             "properties": {
-                "announcements": {
+                "groups": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Announcement"
+                        "$ref": "#/definitions/models.Group"
+                    }
+                }
+            }
+        },
+        "nodes.heartbeatRequest": {
+            "type": "object",
+            "properties": {
+                "agentVersion": {
+                    "type": "string"
+                },
+                "load": {
+                    "description": "1, 5 and 15 minutes load average",
+                    "type": "array",
+                    "items": {
+                        "type": "number"
+                    }
+                },
+                "onlineUsers": {
+                    "description": "users with traffic since last heartbeat",
+                    "type": "integer"
+                },
+                "uptime": {
+                    "description": "seconds",
+                    "type": "integer"
+                }
+            }
+        },
+        "nodes.heartbeatResponse": {
+            "type": "object",
+            "properties": {
+                "node": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Node"
+                }
+            }
+        },
+        "nodes.historyResponse": {
+            "type": "object",
+            "properties": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TrafficPoint"
+                    }
+                },
+                "interval": {
+                    "type": "string"
+                }
+            }
+        },
+        "nodes.indexResponse": {
+            "type": "object",
+            "properties": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Node"
                     }
                 },
                 "total": {
@@ -2116,197 +5013,224 @@ var doc = `{
                 }
             }
         },
-        "groups.createResponse": {
+        "nodes.reportRequest": {
             "type": "object",
+            "required": [
+                "traffic"
+            ],
             "properties": {
-                "group": {
+                "traffic": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/nodes.serviceTraffic"
+                    }
+                }
+            }
+        },
+        "nodes.reportResponse": {
+            "type": "object",
+            "properties": {
+                "report": {
                     "type": "object",
-                    "$ref": "#/definitions/models.Group"
+                    "$ref": "#/definitions/models.TrafficReport"
+                },
+                "unknown": {
+                    "description": "services not on this node, their traffic is dropped",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 }
             }
         },
-        "groups.destroyResponse": {
+        "nodes.serviceTraffic": {
             "type": "object",
+            "required": [
+                "sid"
+            ],
             "properties": {
-                "group": {
+                "download": {
+                    "type": "integer"
+                },
+                "sid": {
+                    "type": "integer"
+                },
+                "upload": {
+                    "type": "integer"
+                }
+            }
+        },
+        "nodes.servicesResponse": {
+            "type": "object",
+            "properties": {
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Service"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "nodes.showResponse": {
+            "type": "object",
+            "properties": {
+                "node": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Node"
+                }
+            }
+        },
+        "nodes.stateRequest": {
+            "type": "object",
+            "required": [
+                "state"
+            ],
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "message": {
+                    "type": "string"
+                },
+                "start": {
+                    "description": "optional maintenance window",
+                    "type": "string"
+                },
+                "state": {
                     "type": "string"
                 }
             }
         },
-        "groups.indexResponse": {
+        "nodes.stateResponse": {
+            "type": "object",
+            "properties": {
+                "node": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Node"
+                }
+            }
+        },
+        "nodes.trafficRequest": {
             "type": "object",
             "properties": {
-                "groups": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Group"
-                    }
+                "current_traffic": {
+                    "type": "integer"
                 },
-                "total": {
+                "max_traffic": {
                     "type": "integer"
                 }
             }
         },
-        "groups.showResponse": {
+        "nodes.trafficResponse": {
             "type": "object",
             "properties": {
-                "group": {
+                "user": {
                     "type": "object",
-                    "$ref": "#/definitions/models.Group"
+                    "$ref": "#/definitions/models.User"
                 }
             }
         },
-        "groups.updateResponse": {
+        "nodes.updateResponse": {
             "type": "object",
             "properties": {
-                "group": {
+                "node": {
                     "type": "object",
-                    "$ref": "#/definitions/models.Group"
+                    "$ref": "#/definitions/models.Node"
                 }
             }
         },
-        "groups.userModificationRequest": {
+        "nodes.usersResponse": {
             "type": "object",
-            "required": [
-                "username"
-            ],
             "properties": {
-                "username": {
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
+                }
+            }
+        },
+        "profiles.destroyResponse": {
+            "type": "object",
+            "properties": {
+                "profile": {
                     "type": "string"
                 }
             }
         },
-        "groups.usersResponse": {
+        "profiles.indexResponse": {
             "type": "object",
             "properties": {
-                "user": {
+                "profiles": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.User"
+                        "$ref": "#/definitions/models.Profile"
                     }
+                },
+                "total": {
+                    "type": "integer"
                 }
             }
         },
-        "handler.ErrorResponse": {
+        "profiles.profileResponse": {
             "type": "object",
             "properties": {
-                "error": {
-                    "type": "string"
+                "profile": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Profile"
                 }
             }
         },
-        "models.Announcement": {
+        "services.destroyResponse": {
             "type": "object",
             "properties": {
-                "content": {
-                    "type": "string"
-                },
-                "created_at": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "level": {
-                    "type": "string"
-                },
-                "title": {
-                    "type": "string"
-                },
-                "updated_at": {
+                "service": {
                     "type": "string"
                 }
             }
         },
-        "models.Group": {
+        "services.indexResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "groupname": {
-                    "type": "string"
+                "services": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Service"
+                    }
                 },
-                "id": {
+                "total": {
                     "type": "integer"
-                },
-                "updated_at": {
-                    "type": "string"
                 }
             }
         },
-        "models.Node": {
+        "services.serviceResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "current_traffic": {
-                    "type": "integer"
-                },
-                "description": {
-                    "type": "string"
-                },
-                "hasMultiPort": {
-                    "type": "boolean"
-                },
-                "hasUDP": {
-                    "type": "boolean"
-                },
-                "host": {
-                    "description": "The Host to access v2ray",
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "listen": {
-                    "type": "string"
-                },
-                "max_traffic": {
-                    "type": "integer"
-                },
-                "name": {
-                    "type": "string"
-                },
-                "port": {
-                    "type": "integer"
-                },
-                "ports": {
-                    "type": "string"
-                },
-                "protocol": {
-                    "type": "string"
-                },
-                "updated_at": {
-                    "type": "string"
+                "service": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.Service"
                 }
             }
         },
-        "models.Service": {
+        "services.storeRequest": {
             "type": "object",
+            "required": [
+                "nid",
+                "uid"
+            ],
             "properties": {
-                "alterid": {
-                    "type": "integer"
-                },
-                "created_at": {
-                    "type": "string"
-                },
                 "description": {
                     "type": "string"
                 },
-                "email": {
-                    "type": "string"
-                },
                 "host": {
                     "type": "string"
                 },
-                "id": {
-                    "type": "integer"
-                },
                 "name": {
                     "type": "string"
                 },
@@ -2319,137 +5243,200 @@ var doc = `{
                 "protocol": {
                     "type": "string"
                 },
-                "security": {
-                    "type": "string"
+                "shadowsocksSettings": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.ShadowsocksSetting"
+                },
+                "shadowsocksUser": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.ShadowsocksUser"
+                },
+                "trojanUser": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.TrojanUser"
                 },
                 "uid": {
                     "type": "integer"
                 },
-                "updated_at": {
-                    "type": "string"
+                "vlessUser": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.VlessUser"
                 },
-                "uuid": {
-                    "type": "string"
+                "vmessSettings": {
+                    "type": "object",
+                    "$ref": "#/definitions/models.VmessSetting"
                 }
             }
         },
-        "models.ShadowsocksSetting": {
-            "type": "object"
-        },
-        "models.User": {
+        "users.destroyResponse": {
             "type": "object",
             "properties": {
-                "created_at": {
-                    "type": "string"
-                },
-                "current_traffic": {
-                    "type": "integer"
-                },
-                "email": {
-                    "type": "string"
-                },
-                "id": {
-                    "type": "integer"
-                },
-                "max_traffic": {
-                    "type": "integer"
-                },
-                "subscription_token": {
-                    "type": "string"
-                },
-                "updated_at": {
+                "user": {
                     "type": "string"
-                },
-                "username": {
+                }
+            }
+        },
+        "users.enrollTOTPResponse": {
+            "type": "object",
+            "properties": {
+                "secret": {
                     "type": "string"
                 },
-                "uuid": {
+                "uri": {
+                    "description": "otpauth:// uri for qr codes",
                     "type": "string"
                 }
             }
         },
-        "models.VmessSetting": {
+        "users.groupsResponse": {
             "type": "object",
             "properties": {
-                "protocol": {
-                    "type": "string"
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Group"
+                    }
                 }
             }
         },
-        "nodes.accessTokenResponse": {
+        "users.historyResponse": {
             "type": "object",
             "properties": {
-                "access_token": {
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.TrafficPoint"
+                    }
+                },
+                "interval": {
                     "type": "string"
                 }
             }
         },
-        "nodes.createResponse": {
+        "users.indexResponse": {
             "type": "object",
             "properties": {
-                "node": {
-                    "type": "object",
-                    "$ref": "#/definitions/models.Node"
+                "total": {
+                    "type": "integer"
+                },
+                "users": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.User"
+                    }
                 }
             }
         },
-        "nodes.destroyResponse": {
+        "users.nodesResponse": {
             "type": "object",
             "properties": {
-                "node": {
+                "nodes": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/models.Node"
+                    }
+                }
+            }
+        },
+        "users.passwordRequest": {
+            "type": "object",
+            "required": [
+                "new_password",
+                "password"
+            ],
+            "properties": {
+                "new_password": {
+                    "type": "string"
+                },
+                "password": {
+                    "description": "current password",
                     "type": "string"
                 }
             }
         },
-        "nodes.indexResponse": {
+        "users.recoveryCodesResponse": {
+            "type": "object",
+            "properties": {
+                "recovery_codes": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "users.servicesResponse": {
             "type": "object",
             "properties": {
-                "nodes": {
+                "services": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Node"
+                        "$ref": "#/definitions/models.Service"
                     }
-                },
-                "total": {
-                    "type": "integer"
                 }
             }
         },
-        "nodes.servicesResponse": {
+        "users.sessionsResponse": {
             "type": "object",
             "properties": {
-                "services": {
+                "sessions": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Service"
+                        "$ref": "#/definitions/models.Session"
                     }
-                },
-                "total": {
-                    "type": "integer"
                 }
             }
         },
-        "nodes.showResponse": {
+        "users.showResponse": {
             "type": "object",
             "properties": {
-                "node": {
+                "user": {
                     "type": "object",
-                    "$ref": "#/definitions/models.Node"
+                    "$ref": "#/definitions/models.User"
                 }
             }
         },
-        "nodes.trafficRequest": {
+        "users.totpRequest": {
+            "type": "object",
+            "required": [
+                "code"
+            ],
+            "properties": {
+                "code": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.trafficRequest": {
             "type": "object",
             "properties": {
                 "current_traffic": {
                     "type": "integer"
                 },
+                "expire_at": {
+                    "description": "unix timestamp, negative value clears it",
+                    "type": "integer"
+                },
                 "max_traffic": {
                     "type": "integer"
                 }
             }
         },
-        "nodes.trafficResponse": {
+        "users.updateRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "subscription_token": {
+                    "type": "string"
+                },
+                "uuid": {
+                    "type": "string"
+                }
+            }
+        },
+        "users.updateResponse": {
             "type": "object",
             "properties": {
                 "user": {
@@ -2458,202 +5445,349 @@ var doc = `{
                 }
             }
         },
-        "nodes.updateResponse": {
+        "users.userResponse": {
             "type": "object",
             "properties": {
-                "node": {
+                "user": {
                     "type": "object",
-                    "$ref": "#/definitions/models.Node"
+                    "$ref": "#/definitions/models.User"
                 }
             }
         },
-        "nodes.usersResponse": {
+        "v2ray.Config": {
             "type": "object",
             "properties": {
-                "total": {
-                    "type": "integer"
+                "api": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.apiConfig"
                 },
-                "users": {
+                "inbounds": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.User"
+                        "$ref": "#/definitions/v2ray.inbound"
                     }
+                },
+                "log": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.logConfig"
+                },
+                "outbounds": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2ray.outbound"
+                    }
+                },
+                "policy": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.policy"
+                },
+                "routing": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.routing"
+                },
+                "stats": {
+                    "type": "object"
                 }
             }
         },
-        "services.destroyResponse": {
-            "type": "object",
-            "properties": {
-                "service": {
-                    "type": "string"
-                }
-            }
-        },
-        "services.indexResponse": {
+        "v2ray.apiConfig": {
             "type": "object",
             "properties": {
                 "services": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Service"
+                        "type": "string"
                     }
                 },
-                "total": {
-                    "type": "integer"
+                "tag": {
+                    "type": "string"
                 }
             }
         },
-        "services.serviceResponse": {
+        "v2ray.certificate": {
             "type": "object",
             "properties": {
-                "service": {
-                    "type": "object",
-                    "$ref": "#/definitions/models.Service"
+                "certificateFile": {
+                    "type": "string"
+                },
+                "keyFile": {
+                    "type": "string"
                 }
             }
         },
-        "services.storeRequest": {
+        "v2ray.client": {
             "type": "object",
-            "required": [
-                "nid",
-                "uid"
-            ],
             "properties": {
-                "description": {
+                "alterId": {
+                    "type": "integer"
+                },
+                "email": {
                     "type": "string"
                 },
-                "host": {
+                "flow": {
                     "type": "string"
                 },
-                "name": {
+                "id": {
                     "type": "string"
                 },
-                "nid": {
+                "level": {
                     "type": "integer"
                 },
+                "method": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2ray.grpcSettings": {
+            "type": "object",
+            "properties": {
+                "serviceName": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2ray.inbound": {
+            "type": "object",
+            "properties": {
+                "listen": {
+                    "type": "string"
+                },
                 "port": {
                     "type": "integer"
                 },
                 "protocol": {
                     "type": "string"
                 },
-                "shadowsocksSettings": {
+                "settings": {
                     "type": "object",
-                    "$ref": "#/definitions/models.ShadowsocksSetting"
+                    "$ref": "#/definitions/v2ray.inboundSettings"
                 },
-                "uid": {
-                    "type": "integer"
+                "sniffing": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.sniffing"
                 },
-                "vmessSettings": {
+                "streamSettings": {
                     "type": "object",
-                    "$ref": "#/definitions/models.VmessSetting"
+                    "$ref": "#/definitions/v2ray.streamSettings"
+                },
+                "tag": {
+                    "type": "string"
                 }
             }
         },
-        "users.destroyResponse": {
+        "v2ray.inboundSettings": {
             "type": "object",
             "properties": {
-                "user": {
+                "address": {
+                    "type": "string"
+                },
+                "clients": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2ray.client"
+                    }
+                },
+                "decryption": {
+                    "description": "vless",
+                    "type": "string"
+                },
+                "email": {
+                    "type": "string"
+                },
+                "method": {
+                    "description": "shadowsocks and dokodemo-door",
+                    "type": "string"
+                },
+                "network": {
+                    "type": "string"
+                },
+                "password": {
                     "type": "string"
                 }
             }
         },
-        "users.groupsResponse": {
+        "v2ray.levelPolicy": {
             "type": "object",
             "properties": {
-                "groups": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.Group"
-                    }
+                "statsUserDownlink": {
+                    "type": "boolean"
+                },
+                "statsUserUplink": {
+                    "type": "boolean"
                 }
             }
         },
-        "users.indexResponse": {
+        "v2ray.logConfig": {
             "type": "object",
             "properties": {
-                "total": {
-                    "type": "integer"
+                "loglevel": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2ray.outbound": {
+            "type": "object",
+            "properties": {
+                "protocol": {
+                    "type": "string"
                 },
-                "users": {
-                    "type": "array",
-                    "items": {
-                        "$ref": "#/definitions/models.User"
+                "tag": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2ray.policy": {
+            "type": "object",
+            "properties": {
+                "levels": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "$ref": "#/definitions/v2ray.levelPolicy"
                     }
+                },
+                "system": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.systemPolicy"
                 }
             }
         },
-        "users.nodesResponse": {
+        "v2ray.realitySettings": {
             "type": "object",
             "properties": {
-                "nodes": {
+                "dest": {
+                    "type": "string"
+                },
+                "privateKey": {
+                    "type": "string"
+                },
+                "serverNames": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Node"
+                        "type": "string"
+                    }
+                },
+                "shortIds": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
                     }
+                },
+                "show": {
+                    "type": "boolean"
+                },
+                "xver": {
+                    "type": "integer"
                 }
             }
         },
-        "users.servicesResponse": {
+        "v2ray.routing": {
             "type": "object",
             "properties": {
-                "services": {
+                "rules": {
                     "type": "array",
                     "items": {
-                        "$ref": "#/definitions/models.Service"
+                        "$ref": "#/definitions/v2ray.rule"
                     }
                 }
             }
         },
-        "users.showResponse": {
+        "v2ray.rule": {
             "type": "object",
             "properties": {
-                "user": {
-                    "type": "object",
-                    "$ref": "#/definitions/models.User"
+                "inboundTag": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "outboundTag": {
+                    "type": "string"
+                },
+                "type": {
+                    "type": "string"
                 }
             }
         },
-        "users.trafficRequest": {
+        "v2ray.sniffing": {
             "type": "object",
             "properties": {
-                "current_traffic": {
-                    "type": "integer"
+                "destOverride": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 },
-                "max_traffic": {
-                    "type": "integer"
+                "enabled": {
+                    "type": "boolean"
                 }
             }
         },
-        "users.updateRequest": {
+        "v2ray.streamSettings": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "grpcSettings": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.grpcSettings"
                 },
-                "subscription_token": {
+                "network": {
                     "type": "string"
                 },
-                "uuid": {
+                "realitySettings": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.realitySettings"
+                },
+                "security": {
                     "type": "string"
+                },
+                "tlsSettings": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.tlsSettings"
+                },
+                "wsSettings": {
+                    "type": "object",
+                    "$ref": "#/definitions/v2ray.wsSettings"
                 }
             }
         },
-        "users.updateResponse": {
+        "v2ray.systemPolicy": {
             "type": "object",
             "properties": {
-                "user": {
-                    "type": "object",
-                    "$ref": "#/definitions/models.User"
+                "statsInboundDownlink": {
+                    "type": "boolean"
+                },
+                "statsInboundUplink": {
+                    "type": "boolean"
                 }
             }
         },
-        "users.userResponse": {
+        "v2ray.tlsSettings": {
             "type": "object",
             "properties": {
-                "user": {
+                "certificates": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/v2ray.certificate"
+                    }
+                },
+                "serverName": {
+                    "type": "string"
+                }
+            }
+        },
+        "v2ray.wsSettings": {
+            "type": "object",
+            "properties": {
+                "headers": {
                     "type": "object",
-                    "$ref": "#/definitions/models.User"
+                    "additionalProperties": {
+                        "type": "string"
+                    }
+                },
+                "path": {
+                    "type": "string"
                 }
             }
         }
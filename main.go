@@ -33,8 +33,10 @@ import (
 	v1 "github.com/coolray-dev/raydash/api/v1"
 	_ "github.com/coolray-dev/raydash/docs"
 	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/lockout"
 	"github.com/coolray-dev/raydash/modules/log"
 	"github.com/coolray-dev/raydash/modules/mail"
+	"github.com/coolray-dev/raydash/modules/scheduler"
 	"github.com/coolray-dev/raydash/modules/setting"
 )
 
@@ -60,6 +62,23 @@ func main() {
 	mailWorker := mail.NewWorker(mailCfg, mail.MailChan, &wg)
 	mailWorker.Start()
 
+	// init scheduler
+	schedulerInterval := setting.Config.GetDuration("scheduler.interval")
+	if schedulerInterval <= 0 {
+		schedulerInterval = 10 * time.Minute
+	}
+	jobScheduler := scheduler.NewScheduler(schedulerInterval, &wg)
+	jobScheduler.Add(&scheduler.UserResetJob{
+		Mode: setting.Config.GetString("scheduler.reset.mode"),
+		Day:  setting.Config.GetInt("scheduler.reset.day"),
+	})
+	jobScheduler.Add(&scheduler.NodeResetJob{})
+	jobScheduler.Add(&scheduler.ExpiryJob{})
+	jobScheduler.Add(&scheduler.MaintenanceEndJob{})
+	jobScheduler.Add(&scheduler.LoginAttemptPruneJob{Guard: lockout.Default})
+	jobScheduler.Add(&scheduler.SessionPruneJob{})
+	jobScheduler.Start()
+
 	// init router
 	router := gin.Default()
 
@@ -84,12 +103,12 @@ func main() {
 	}
 
 	// Create channel to catch system signal
-	sigs := make(chan os.Signal)
+	sigs := make(chan os.Signal, 1)
 	signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
 
 	// Monitor signal from channel sigs
+	wg.Add(1)
 	go func() {
-		wg.Add(1)
 		sig := <-sigs
 
 		// Do graceful shutdown
@@ -97,6 +116,8 @@ func main() {
 		log.Log.Info("Shutting Down")
 		log.Log.Info("Stopping MailWorker")
 		mailWorker.Stop()
+		log.Log.Info("Stopping Scheduler")
+		jobScheduler.Stop()
 		log.Log.Info("Stopping Gin")
 		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 		defer cancel()
@@ -6,4 +6,6 @@ type Group struct {
 	Description string  `json:"description"`
 	Users       []*User `gorm:"many2many:groups_users;" json:"-"`
 	Nodes       []*Node `gorm:"many2many:groups_nodes;" json:"-"`
+	ProfileID   *uint64 `json:"profile_id"`  // clash profile of group members
+	RequireMFA  bool    `json:"require_mfa"` // members must enable two-factor authentication to login
 }
@@ -0,0 +1,12 @@
+package models
+
+import "time"
+
+// LoginAttempt records failed logins of a username or client ip,
+// used by the database store of lockout module
+type LoginAttempt struct {
+	Name        string `gorm:"primaryKey"` // user:<username> or ip:<address>
+	Failures    int    // failures in a row
+	LastFailure time.Time
+	LockedUntil time.Time // zero means not locked
+}
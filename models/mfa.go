@@ -0,0 +1,160 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/modules/totp"
+	"github.com/coolray-dev/raydash/modules/utils"
+)
+
+// RecoveryCodeCount is the number of recovery codes issued at a time
+const RecoveryCodeCount = 10
+
+// ErrCodeUsed is returned by SaveTOTP when the code checked since the user
+// was loaded has been used by another request meanwhile
+var ErrCodeUsed = errors.New("Code already used")
+
+// TOTP is the two-factor authentication state of a user, embedded in User
+type TOTP struct {
+	TOTPSecret    string `gorm:"column:totp_secret" json:"-" fake:"skip"` // set on enrollment, in effect once confirmed
+	TOTPEnabled   bool   `gorm:"column:totp_enabled" json:"totp_enabled" fake:"skip"`
+	TOTPLastStep  int64  `gorm:"column:totp_last_step" json:"-" fake:"skip"` // codes of this step or earlier are used up
+	RecoveryCodes string `gorm:"column:recovery_codes" json:"-" fake:"skip"` // json list of hashes of unused recovery codes
+
+	// Codes used since the user was loaded, SaveTOTP only succeeds if they
+	// are still unused in database
+	usedStep  int64
+	usedCodes *string // recovery codes before one was used
+}
+
+// MFAForced tells whether any group of the user requires two-factor authentication
+func (user *User) MFAForced(tx *gorm.DB) (bool, error) {
+	var count int64
+	err := tx.Model(&Group{}).
+		Where("require_mfa = ?", true).
+		Where("id IN (?)", tx.Table("groups_users").Select("group_id").Where("user_id = ?", user.ID)).
+		Count(&count).Error
+	return count > 0, err
+}
+
+// EnrollTOTP generates a new secret pending confirmation and returns its otpauth uri
+func (user *User) EnrollTOTP() (string, error) {
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return "", err
+	}
+	user.TOTPSecret = secret
+	user.TOTPEnabled = false
+	user.TOTPLastStep = 0
+	user.RecoveryCodes = ""
+	return totp.URI("RayDash", user.Username, secret), nil
+}
+
+// ConfirmTOTP enables the pending secret if code matches it and returns
+// new recovery codes, nil means the code is wrong
+func (user *User) ConfirmTOTP(code string, now time.Time) ([]string, error) {
+	if user.TOTPSecret == "" || !user.checkCode(code, now) {
+		return nil, nil
+	}
+	user.TOTPEnabled = true
+	return user.NewRecoveryCodes()
+}
+
+// NewRecoveryCodes replaces recovery codes of user and returns them in plain
+func (user *User) NewRecoveryCodes() ([]string, error) {
+	codes, err := totp.GenerateRecoveryCodes(RecoveryCodeCount)
+	if err != nil {
+		return nil, err
+	}
+	hashes := make([]string, len(codes))
+	for i, c := range codes {
+		hashes[i] = utils.Hash(c)
+	}
+	b, err := json.Marshal(hashes)
+	if err != nil {
+		return nil, err
+	}
+	user.RecoveryCodes = string(b)
+	return codes, nil
+}
+
+// DisableTOTP turns two-factor authentication off and forgets the secret,
+// the code used to do so stays checked by SaveTOTP
+func (user *User) DisableTOTP() {
+	user.TOTP = TOTP{usedStep: user.usedStep, usedCodes: user.usedCodes}
+}
+
+// CheckSecondFactor accepts a totp code or an unused recovery code, either
+// is used up by a successful check so the user must be saved after it
+func (user *User) CheckSecondFactor(code string, now time.Time) bool {
+	if !user.TOTPEnabled {
+		return false
+	}
+	if user.checkCode(code, now) {
+		return true
+	}
+	return user.useRecoveryCode(code)
+}
+
+// checkCode matches a totp code, refusing steps already used
+func (user *User) checkCode(code string, now time.Time) bool {
+	step := totp.Validate(user.TOTPSecret, code, now)
+	if step == 0 || step <= user.TOTPLastStep {
+		return false
+	}
+	user.TOTPLastStep = step
+	user.usedStep = step
+	return true
+}
+
+func (user *User) useRecoveryCode(code string) bool {
+	var hashes []string
+	if user.RecoveryCodes == "" || json.Unmarshal([]byte(user.RecoveryCodes), &hashes) != nil {
+		return false
+	}
+	hash := utils.Hash(strings.ToLower(strings.TrimSpace(code)))
+	for i, h := range hashes {
+		if h != hash {
+			continue
+		}
+		hashes = append(hashes[:i], hashes[i+1:]...)
+		b, _ := json.Marshal(hashes)
+		codes := user.RecoveryCodes
+		user.usedCodes = &codes
+		user.RecoveryCodes = string(b)
+		return true
+	}
+	return false
+}
+
+// SaveTOTP writes two-factor authentication state of user only. A code used
+// since the user was loaded is used up in the same statement, so concurrent
+// requests with the same code cannot both pass, the later gets ErrCodeUsed.
+func (user *User) SaveTOTP(tx *gorm.DB) error {
+	query := tx.Model(user)
+	if user.usedStep != 0 {
+		query = query.Where("totp_last_step < ?", user.usedStep)
+	}
+	if user.usedCodes != nil {
+		query = query.Where("recovery_codes = ?", *user.usedCodes)
+	}
+	result := query.UpdateColumns(map[string]interface{}{
+		"totp_secret":    user.TOTPSecret,
+		"totp_enabled":   user.TOTPEnabled,
+		"totp_last_step": user.TOTPLastStep,
+		"recovery_codes": user.RecoveryCodes,
+	})
+	if result.Error != nil {
+		return result.Error
+	}
+	if (user.usedStep != 0 || user.usedCodes != nil) && result.RowsAffected == 0 {
+		return ErrCodeUsed
+	}
+	user.usedStep, user.usedCodes = 0, nil
+	return nil
+}
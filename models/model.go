@@ -10,8 +10,8 @@ import (
 	"gorm.io/gorm"
 
 	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/modules/password"
 	"github.com/coolray-dev/raydash/modules/setting"
-	"github.com/coolray-dev/raydash/modules/utils"
 )
 
 type BaseModel struct {
@@ -34,10 +34,15 @@ func init() {
 		os.Exit(1)
 	}
 	if err := orm.DB.Where("username = ?", "admin").First(&User{}).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		hash, err := password.Hash(setting.Config.GetString("app.adminpassword"))
+		if err != nil {
+			fmt.Println("Database Seeding Error: ", err)
+			os.Exit(1)
+		}
 		var admin User = User{
 			UUID:     uuid.New().String(),
 			Username: "admin",
-			Password: utils.Hash(setting.Config.GetString("app.adminpassword")),
+			Password: hash,
 		}
 		var adminGroup Group
 		if err := orm.DB.Where("name = ?", "admin").First(&adminGroup).Error; err != nil {
@@ -59,6 +64,15 @@ func migrate() {
 		&ForgetPassword{},
 		&Option{},
 		&Service{},
-		&Announcement{})
+		&Announcement{},
+		&Profile{},
+		&TrafficReport{},
+		&TrafficLog{},
+		&TrafficCycle{},
+		&LoginAttempt{},
+		&Session{})
 
+	// Services created before vmess moved to AEAD have alterId 64,
+	// which xray nodes reject
+	orm.DB.Model(&Service{}).Where("alter_id <> 0").UpdateColumn("alter_id", 0)
 }
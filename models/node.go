@@ -1,25 +1,304 @@
 package models
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/modules/setting"
+)
+
 // Node is a struct of node info
 type Node struct {
 	BaseModel
-	Name           string     `json:"name"`
-	Description    string     `json:"description"`
+	Name           string   `json:"name"`
+	Description    string   `json:"description"`
+	Region         string   `json:"region"` // ISO 3166-1 alpha-2 country code such as HK
+	Tags           []string `gorm:"-" json:"tags"`
+	TagsStr        string   `gorm:"column:tags" json:"-"`        // gorm doesn't support slice so tags are marshaled here
+	Sort           int      `json:"sort"`                        // nodes are listed by ascending sort
+	Multiplier     *float64 `gorm:"default:1" json:"multiplier"` // user quota charged per byte of traffic, 1 when absent
+	State          string   `gorm:"default:active" json:"state"`
+	Maintenance    `json:"maintenance"`
 	Groups         []*Group   `gorm:"many2many:groups_nodes;" json:"-"`
 	Services       []*Service `json:"-"`
-	Host           string     `json:"host"` // The Host to access v2ray
-	Ports          string     `json:"ports"`
+	Host           string     `json:"host"`  // The Host to access v2ray
+	Ports          string     `json:"ports"` // ports for services of multi port node, such as 10000-10999,20000
 	AccessToken    string     `json:"-"`
 	CurrentTraffic uint64     `json:"current_traffic"`
 	MaxTraffic     uint64     `json:"max_traffic"`
+	ResetDay       uint       `json:"reset_day"`     // day of month to reset traffic, 0 disables
+	LastResetAt    *time.Time `json:"last_reset_at"` // start of current traffic cycle, nil until the scheduler first runs
 	HasUDP         bool       `json:"hasUDP"`
 	HasMultiPort   bool       `json:"hasMultiPort"`
 	Settings       `json:"settings"`
+	Heartbeat      `json:"heartbeat"`
+	Online         bool `gorm:"-" json:"online"` // computed from LastSeenAt after loading
+}
+
+// Node states, nodes not active are hidden from users but keep their services
+const (
+	NodeActive      = "active"
+	NodeMaintenance = "maintenance"
+	NodeDisabled    = "disabled"
+)
+
+// Maintenance describes a maintenance of the node, the window is optional
+// and a node in it is hidden like one in maintenance state
+type Maintenance struct {
+	MaintenanceStart   *time.Time `json:"start"`
+	MaintenanceEnd     *time.Time `json:"end"`
+	MaintenanceMessage string     `json:"message"`
+}
+
+// InWindow tells whether now is within the maintenance window,
+// a window missing start or end is open on that side
+func (m *Maintenance) InWindow(now time.Time) bool {
+	if m.MaintenanceStart == nil && m.MaintenanceEnd == nil {
+		return false
+	}
+	if m.MaintenanceStart != nil && now.Before(*m.MaintenanceStart) {
+		return false
+	}
+	if m.MaintenanceEnd != nil && !now.Before(*m.MaintenanceEnd) {
+		return false
+	}
+	return true
+}
+
+// Available tells whether the node should be shown to users at now
+func (n *Node) Available(now time.Time) bool {
+	return (n.State == "" || n.State == NodeActive) && !n.Maintenance.InWindow(now)
+}
+
+// Heartbeat is the last status reported by the agent on the node
+type Heartbeat struct {
+	LastSeenAt   *time.Time `json:"lastSeenAt"`
+	Uptime       uint64     `json:"uptime"` // seconds
+	Load1        float64    `json:"load1"`
+	Load5        float64    `json:"load5"`
+	Load15       float64    `json:"load15"`
+	OnlineUsers  uint       `json:"onlineUsers"`
+	AgentVersion string     `json:"agentVersion"`
+}
+
+// DefaultNodeTimeout is used when node.timeout is not configured
+const DefaultNodeTimeout = 3 * time.Minute
+
+// NodeTimeout returns how long a node stays online after its last heartbeat
+func NodeTimeout() time.Duration {
+	if t := setting.Config.GetDuration("node.timeout"); t > 0 {
+		return t
+	}
+	return DefaultNodeTimeout
+}
+
+// IsOnline tells whether the node sent a heartbeat within timeout before now
+func (n *Node) IsOnline(now time.Time, timeout time.Duration) bool {
+	return n.LastSeenAt != nil && now.Sub(*n.LastSeenAt) <= timeout
+}
+
+// AfterFind computes online status of the node and unmarshals tags
+// This is a GORM feature called hook
+func (n *Node) AfterFind(*gorm.DB) error {
+	n.Online = n.IsOnline(time.Now(), NodeTimeout())
+	if n.TagsStr == "" {
+		return nil
+	}
+	return json.Unmarshal([]byte(n.TagsStr), &n.Tags)
 }
 
 type Settings struct {
+	Protocol           string `json:"protocol"` // vmess, vless, trojan or shadowsocks, empty means vmess
 	Listen             string `json:"listen"`
 	Port               uint   `json:"port"`
 	VmessSetting       `json:"vmessSettings"`
 	ShadowsocksSetting `json:"shadowsocksSettings"`
+	VlessSetting       `json:"vlessSettings"`
+	RealitySetting     `json:"realitySettings"`
+	TLSSetting         `json:"tlsSettings"`
+}
+
+// TLSSetting is where the certificate of tls stream security is on the node
+type TLSSetting struct {
+	CertificateFile string `json:"certificateFile"`
+	KeyFile         string `json:"keyFile"`
+}
+
+type VlessSetting struct {
+	Flow string `json:"flow"` // flow given to services of the node
+}
+
+// RealitySetting is the server side of reality, public key and short id
+// are in stream settings since clients need them
+type RealitySetting struct {
+	PrivateKey string `gorm:"column:reality_private_key" json:"-"`
+	Dest       string `gorm:"column:reality_dest" json:"dest"` // defaults to serverName:443
+}
+
+// Validate checks settings of the node protocol
+func (s *Settings) Validate() error {
+	return validateProtocol(s.Protocol, &s.StreamSettings, &s.ShadowsocksSetting, s.VlessSetting.Flow)
+}
+
+// Validate checks region, multiplier and settings of the node
+func (n *Node) Validate() error {
+	if n.Region != "" && !regionPattern.MatchString(n.Region) {
+		return fmt.Errorf("Invalid region %q, should be a two letter country code", n.Region)
+	}
+	if n.Multiplier != nil && *n.Multiplier < 0 {
+		return fmt.Errorf("Invalid multiplier %v", *n.Multiplier)
+	}
+	if _, err := ParsePorts(n.Ports); err != nil {
+		return err
+	}
+	switch n.State {
+	case "", NodeActive, NodeMaintenance, NodeDisabled:
+	default:
+		return fmt.Errorf("Invalid state %q", n.State)
+	}
+	if m := n.Maintenance; m.MaintenanceStart != nil && m.MaintenanceEnd != nil && !m.MaintenanceEnd.After(*m.MaintenanceStart) {
+		return errors.New("Maintenance should end after it starts")
+	}
+	return n.Settings.Validate()
+}
+
+var regionPattern = regexp.MustCompile(`^[A-Za-z]{2}$`)
+
+// ParseTags splits comma separated tags, tags are trimmed, lower cased and deduplicated
+func ParseTags(s string) []string {
+	return normalizeTags(strings.Split(s, ","))
+}
+
+func normalizeTags(tags []string) []string {
+	seen := make(map[string]bool)
+	var result []string
+	for _, t := range tags {
+		t = strings.ToLower(strings.TrimSpace(t))
+		if t == "" || seen[t] {
+			continue
+		}
+		seen[t] = true
+		result = append(result, t)
+	}
+	return result
+}
+
+// HasAnyTag tells whether the node has one of tags, no tags matches every node
+func (n *Node) HasAnyTag(tags []string) bool {
+	if len(tags) == 0 {
+		return true
+	}
+	for _, t := range tags {
+		for _, own := range n.Tags {
+			if t == own {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// SortNodes orders nodes by sort then id
+func SortNodes(nodes []*Node) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].Sort != nodes[j].Sort {
+			return nodes[i].Sort < nodes[j].Sort
+		}
+		return nodes[i].ID < nodes[j].ID
+	})
+}
+
+// Charge returns the user quota used by traffic on this node
+func (n *Node) Charge(traffic uint64) uint64 {
+	if n.Multiplier == nil {
+		return traffic
+	}
+	return uint64(math.Round(float64(traffic) * *n.Multiplier))
+}
+
+// BeforeSave defaults multiplier to 1, marshals tags and generates the server key of shadowsocks 2022 methods and reality keys
+// This is a GORM feature called hook
+func (n *Node) BeforeSave(*gorm.DB) error {
+	// A pointer so that a multiplier of 0 is not taken as absent
+	if n.Multiplier == nil {
+		one := 1.0
+		n.Multiplier = &one
+	}
+	n.Region = strings.ToUpper(n.Region)
+	n.Tags = normalizeTags(n.Tags)
+	b, err := json.Marshal(&n.Tags)
+	if err != nil {
+		return err
+	}
+	n.TagsStr = string(b)
+
+	if n.Protocol == ProtocolShadowsocks && n.ShadowsocksSetting.Is2022() && n.ServerKey == "" {
+		key, err := n.ShadowsocksSetting.GeneratePassword()
+		if err != nil {
+			return err
+		}
+		n.ServerKey = key
+	}
+	if n.StreamSecurity == "reality" {
+		if n.RealitySetting.PrivateKey == "" {
+			private, public, err := GenerateRealityKey()
+			if err != nil {
+				return err
+			}
+			n.RealitySetting.PrivateKey = private
+			n.RealityPublicKey = public
+		}
+		if n.RealityShortID == "" {
+			id, err := GenerateRealityShortID()
+			if err != nil {
+				return err
+			}
+			n.RealityShortID = id
+		}
+	}
+	return nil
+}
+
+// ApplyTo fills a service of user with the settings of this single port node,
+// credentials already in the service are kept
+func (n *Node) ApplyTo(s *Service, u *User) error {
+	s.Host = n.Host
+	s.Port = n.Settings.Port
+	switch n.Protocol {
+	case ProtocolShadowsocks:
+		s.Protocol = ProtocolShadowsocks
+		s.ShadowsocksSetting = n.Settings.ShadowsocksSetting
+		s.VmessUser.Email = u.Email // node identifies users by email
+	case ProtocolTrojan:
+		s.Protocol = ProtocolTrojan
+		s.VmessSetting = n.Settings.VmessSetting
+		s.VmessUser.Email = u.Email
+	case ProtocolVless:
+		s.Protocol = ProtocolVless
+		s.VmessSetting = n.Settings.VmessSetting
+		s.VmessUser.Email = u.Email
+		s.VlessUser = VlessUser{
+			UUID: u.UUID,
+			Flow: n.Settings.VlessSetting.Flow,
+		}
+	default:
+		// AlterID 0 is AEAD, the only vmess xray accepts
+		s.Protocol = ProtocolVmess
+		s.VmessSetting = n.Settings.VmessSetting
+		s.VmessUser = VmessUser{
+			Email:    u.Email,
+			UUID:     u.UUID,
+			AlterID:  0,
+			Security: "auto",
+		}
+	}
+	return s.GenerateCredentials()
 }
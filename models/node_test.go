@@ -0,0 +1,80 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestNodeTags(t *testing.T) {
+	assert := assertlib.New(t)
+
+	assert.Equal([]string{"hk", "premium"}, ParseTags(" HK,premium,,hk "))
+	assert.Empty(ParseTags(""))
+
+	n := Node{Tags: []string{"hk", "premium"}}
+	assert.True(n.HasAnyTag(nil))
+	assert.True(n.HasAnyTag([]string{"jp", "hk"}))
+	assert.False(n.HasAnyTag([]string{"jp"}))
+}
+
+func TestNodeValidate(t *testing.T) {
+	cases := []struct {
+		Name  string
+		Node  Node
+		Valid bool
+	}{
+		{"Empty", Node{}, true},
+		{"Region", Node{Region: "hk", Multiplier: multiplier(2)}, true},
+		{"Invalid region", Node{Region: "HKG"}, false},
+		{"Free node", Node{Multiplier: multiplier(0)}, true},
+		{"Negative multiplier", Node{Multiplier: multiplier(-1)}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			err := c.Node.Validate()
+			assertlib.Equal(t, c.Valid, err == nil, "%v", err)
+		})
+	}
+}
+
+func TestNodeCharge(t *testing.T) {
+	assert := assertlib.New(t)
+
+	assert.Equal(uint64(100), (&Node{Multiplier: multiplier(1)}).Charge(100))
+	assert.Equal(uint64(250), (&Node{Multiplier: multiplier(2.5)}).Charge(100))
+	assert.Equal(uint64(0), (&Node{Multiplier: multiplier(0)}).Charge(100))
+	assert.Equal(uint64(100), (&Node{}).Charge(100))
+}
+
+func multiplier(m float64) *float64 {
+	return &m
+}
+
+func TestNodeAvailable(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		Name      string
+		Node      Node
+		Available bool
+	}{
+		{"Default", Node{}, true},
+		{"Active", Node{State: NodeActive}, true},
+		{"Maintenance", Node{State: NodeMaintenance}, false},
+		{"Disabled", Node{State: NodeDisabled}, false},
+		{"In window", Node{Maintenance: Maintenance{MaintenanceStart: &past, MaintenanceEnd: &future}}, false},
+		{"Before window", Node{Maintenance: Maintenance{MaintenanceStart: &future}}, true},
+		{"After window", Node{Maintenance: Maintenance{MaintenanceEnd: &past}}, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assertlib.Equal(t, c.Available, c.Node.Available(now))
+		})
+	}
+}
@@ -1,7 +1,24 @@
 package models
 
+import (
+	orm "github.com/coolray-dev/raydash/database"
+)
+
 type Option struct {
 	BaseModel
 	Name  string `json:"name"`
 	Value string `json:"value"`
 }
+
+// GetOption returns value of the option named name, or def if it is not set
+func GetOption(name string, def string) string {
+	// Find instead of First, a missing option is not worth an error log
+	var opts []Option
+	if err := orm.DB.Where("name = ?", name).Order("updated_at desc").Limit(1).Find(&opts).Error; err != nil {
+		return def
+	}
+	if len(opts) == 0 || opts[0].Value == "" {
+		return def
+	}
+	return opts[0].Value
+}
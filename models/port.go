@@ -0,0 +1,125 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Errors of port allocation
+var (
+	ErrPortInUse      = errors.New("Port is used by another service on the node")
+	ErrPortOutOfRange = errors.New("Port is out of the ports of the node")
+	ErrNoFreePort     = errors.New("No free port left on the node")
+)
+
+// PortRange is an inclusive range of ports
+type PortRange struct {
+	Start uint
+	End   uint
+}
+
+// ParsePorts parses ranges like 10000-10999,20000, empty string means no range
+func ParsePorts(s string) ([]PortRange, error) {
+	var ranges []PortRange
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		bounds := strings.SplitN(part, "-", 2)
+		start, err := parsePort(bounds[0])
+		if err != nil {
+			return nil, err
+		}
+		end := start
+		if len(bounds) == 2 {
+			if end, err = parsePort(bounds[1]); err != nil {
+				return nil, err
+			}
+		}
+		if end < start {
+			return nil, fmt.Errorf("Invalid port range %q", part)
+		}
+		ranges = append(ranges, PortRange{Start: start, End: end})
+	}
+	return ranges, nil
+}
+
+func parsePort(s string) (uint, error) {
+	port, err := strconv.ParseUint(strings.TrimSpace(s), 10, 16)
+	if err != nil || port == 0 {
+		return 0, fmt.Errorf("Invalid port %q", s)
+	}
+	return uint(port), nil
+}
+
+// containsPort tells whether port is in one of ranges
+func containsPort(ranges []PortRange, port uint) bool {
+	for _, r := range ranges {
+		if port >= r.Start && port <= r.End {
+			return true
+		}
+	}
+	return false
+}
+
+// AssignPort gives service s on multi port node n a port. A port already in s
+// is checked against ports of the node and other services, otherwise the lowest
+// free port is allocated. Ports are freed simply by deleting services, so tx
+// should be a transaction that also saves s. The node row is locked until tx
+// ends so concurrent allocations on the node wait for each other.
+func AssignPort(tx *gorm.DB, n *Node, s *Service) error {
+	ranges, err := ParsePorts(n.Ports)
+	if err != nil {
+		return err
+	}
+
+	var node Node
+	if err := forUpdate(tx).Select("id").First(&node, n.ID).Error; err != nil {
+		return err
+	}
+	var used []uint
+	if err := forUpdate(tx).Model(&Service{}).Where("node_id = ? AND id <> ?", n.ID, s.ID).Pluck("port", &used).Error; err != nil {
+		return err
+	}
+	inUse := make(map[uint]bool, len(used))
+	for _, p := range used {
+		inUse[p] = true
+	}
+
+	if s.Port != 0 {
+		// Node without ports accepts any port
+		if len(ranges) > 0 && !containsPort(ranges, s.Port) {
+			return fmt.Errorf("%w: %d not in %s", ErrPortOutOfRange, s.Port, n.Ports)
+		}
+		if inUse[s.Port] {
+			return fmt.Errorf("%w: %d", ErrPortInUse, s.Port)
+		}
+		return nil
+	}
+
+	for _, r := range ranges {
+		for p := r.Start; p <= r.End; p++ {
+			if !inUse[p] {
+				s.Port = p
+				return nil
+			}
+		}
+	}
+	return ErrNoFreePort
+}
+
+// forUpdate makes a query lock the rows it reads and see rows committed by
+// others in the meantime. SQLite has no row locks, but only lets one
+// transaction write at a time and fails the others instead.
+func forUpdate(tx *gorm.DB) *gorm.DB {
+	if tx.Dialector.Name() == "sqlite" {
+		return tx
+	}
+	return tx.Clauses(clause.Locking{Strength: "UPDATE"})
+}
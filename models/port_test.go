@@ -0,0 +1,117 @@
+package models
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	orm "github.com/coolray-dev/raydash/database"
+	assertlib "github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestParsePorts(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Ports  string
+		Ranges []PortRange
+		Valid  bool
+	}{
+		{"Empty", "", nil, true},
+		{"Single", "443", []PortRange{{443, 443}}, true},
+		{"Ranges", "10000-10999, 20000", []PortRange{{10000, 10999}, {20000, 20000}}, true},
+		{"Reversed", "10999-10000", nil, false},
+		{"Zero", "0-10", nil, false},
+		{"Too large", "65536", nil, false},
+		{"Garbage", "10000-", nil, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			ranges, err := ParsePorts(c.Ports)
+			assert.Equal(c.Valid, err == nil, "%v", err)
+			assert.Equal(c.Ranges, ranges)
+		})
+	}
+}
+
+func TestAssignPort(t *testing.T) {
+	assert := assertlib.New(t)
+	tx := orm.DB.Begin()
+	defer tx.Rollback()
+
+	node := Node{Name: "multiport", HasMultiPort: true, Ports: "10000-10001,20000"}
+	assert.Nil(tx.Create(&node).Error)
+
+	create := func(port uint) (*Service, error) {
+		s := &Service{NodeID: node.ID}
+		s.Port = port
+		if err := AssignPort(tx, &node, s); err != nil {
+			return nil, err
+		}
+		return s, tx.Create(s).Error
+	}
+
+	first, err := create(0)
+	assert.Nil(err)
+	assert.Equal(uint(10000), first.Port)
+
+	_, err = create(10000)
+	assert.True(errors.Is(err, ErrPortInUse))
+	_, err = create(30000)
+	assert.True(errors.Is(err, ErrPortOutOfRange))
+
+	second, err := create(20000)
+	assert.Nil(err)
+	third, err := create(0)
+	assert.Nil(err)
+	assert.Equal(uint(10001), third.Port)
+	_, err = create(0)
+	assert.True(errors.Is(err, ErrNoFreePort))
+
+	// Keeping its own port is fine, deleting a service frees its port
+	assert.Nil(AssignPort(tx, &node, second))
+	assert.Nil(tx.Delete(first).Error)
+	fourth, err := create(0)
+	assert.Nil(err)
+	assert.Equal(uint(10000), fourth.Port)
+}
+
+func TestAssignPortConcurrent(t *testing.T) {
+	assert := assertlib.New(t)
+
+	node := Node{Name: "multiport", HasMultiPort: true, Ports: "10000-10999"}
+	assert.Nil(orm.DB.Create(&node).Error)
+	defer orm.DB.Delete(&node)
+	defer orm.DB.Where("node_id = ?", node.ID).Delete(&Service{})
+
+	// Transactions failing to get the node are fine, sharing a port is not
+	var wg sync.WaitGroup
+	ports := make(chan uint, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s := &Service{NodeID: node.ID}
+			err := orm.DB.Transaction(func(tx *gorm.DB) error {
+				if err := AssignPort(tx, &node, s); err != nil {
+					return err
+				}
+				return tx.Create(s).Error
+			})
+			if err == nil {
+				ports <- s.Port
+			}
+		}()
+	}
+	wg.Wait()
+	close(ports)
+
+	seen := make(map[uint]bool)
+	for p := range ports {
+		assert.False(seen[p], "port %d taken twice", p)
+		seen[p] = true
+	}
+	assert.NotEmpty(seen)
+}
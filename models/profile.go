@@ -0,0 +1,157 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// Profile holds the admin managed parts of a clash config,
+// a group could point to its own profile
+type Profile struct {
+	BaseModel
+	Name        string       `json:"name"`
+	Nameservers []string     `json:"nameservers" gorm:"-"`
+	Fallback    []string     `json:"fallback" gorm:"-"`
+	ProxyGroups []ProxyGroup `json:"proxy_groups" gorm:"-"`
+	Rules       []string     `json:"rules" gorm:"-"` // e.g. DOMAIN-SUFFIX,google.com,PROXY
+	Content     string       `json:"-"`              // lists above are marshaled and stored here
+}
+
+// ProxyGroup is an extra clash proxy group, all proxies of the user are appended to it
+type ProxyGroup struct {
+	Name     string   `json:"name"`
+	Type     string   `json:"type"` // select, url-test, fallback or load-balance
+	URL      string   `json:"url"`
+	Interval uint     `json:"interval"`
+	Proxies  []string `json:"proxies"` // other groups, DIRECT or REJECT placed before user proxies
+}
+
+// DefaultProxyGroup is the select group always present in clash config
+const DefaultProxyGroup = "PROXY"
+
+type profileContent struct {
+	Nameservers []string     `json:"nameservers"`
+	Fallback    []string     `json:"fallback"`
+	ProxyGroups []ProxyGroup `json:"proxy_groups"`
+	Rules       []string     `json:"rules"`
+}
+
+var ruleTypes = map[string]bool{
+	"DOMAIN":         true,
+	"DOMAIN-SUFFIX":  true,
+	"DOMAIN-KEYWORD": true,
+	"GEOIP":          true,
+	"IP-CIDR":        true,
+	"IP-CIDR6":       true,
+	"SRC-IP-CIDR":    true,
+	"SRC-PORT":       true,
+	"DST-PORT":       true,
+	"PROCESS-NAME":   true,
+	"RULE-SET":       true,
+	"MATCH":          true,
+}
+
+var groupTypes = map[string]bool{
+	"select":       true,
+	"url-test":     true,
+	"fallback":     true,
+	"load-balance": true,
+}
+
+// Validate checks nameservers, proxy groups and rules could produce a valid clash config
+func (p *Profile) Validate() error {
+	for _, ns := range append(append([]string{}, p.Nameservers...), p.Fallback...) {
+		if ns == "" || strings.ContainsAny(ns, "\r\n") {
+			return fmt.Errorf("Invalid nameserver %q", ns)
+		}
+	}
+
+	targets := map[string]bool{
+		DefaultProxyGroup: true,
+		"DIRECT":          true,
+		"REJECT":          true,
+	}
+	for _, g := range p.ProxyGroups {
+		if g.Name == "" {
+			return fmt.Errorf("Proxy group name is required")
+		}
+		if targets[g.Name] {
+			return fmt.Errorf("Duplicated proxy group %s", g.Name)
+		}
+		if !groupTypes[g.Type] {
+			return fmt.Errorf("Invalid type %s of proxy group %s", g.Type, g.Name)
+		}
+		if g.Type != "select" && (g.URL == "" || g.Interval == 0) {
+			return fmt.Errorf("Proxy group %s of type %s requires url and interval", g.Name, g.Type)
+		}
+		targets[g.Name] = true
+	}
+	for _, g := range p.ProxyGroups {
+		for _, proxy := range g.Proxies {
+			if !targets[proxy] {
+				return fmt.Errorf("Unknown proxy %s in proxy group %s", proxy, g.Name)
+			}
+		}
+	}
+
+	for _, r := range p.Rules {
+		if strings.ContainsAny(r, "\r\n") {
+			return fmt.Errorf("Invalid rule %q", r)
+		}
+		fields := strings.Split(r, ",")
+		if !ruleTypes[fields[0]] {
+			return fmt.Errorf("Unknown type of rule %q", r)
+		}
+
+		// MATCH takes only a target, others take a payload and a target with optional no-resolve
+		switch {
+		case fields[0] == "MATCH" && len(fields) == 2:
+		case fields[0] != "MATCH" && len(fields) == 3:
+		case fields[0] != "MATCH" && len(fields) == 4 && fields[3] == "no-resolve":
+		default:
+			return fmt.Errorf("Invalid rule %q", r)
+		}
+		target := fields[len(fields)-1]
+		if target == "no-resolve" {
+			target = fields[2]
+		}
+		if !targets[target] {
+			return fmt.Errorf("Unknown target %s of rule %q", target, r)
+		}
+	}
+	return nil
+}
+
+// BeforeSave marshal the lists into content
+func (p *Profile) BeforeSave(*gorm.DB) error {
+	b, err := json.Marshal(&profileContent{
+		Nameservers: p.Nameservers,
+		Fallback:    p.Fallback,
+		ProxyGroups: p.ProxyGroups,
+		Rules:       p.Rules,
+	})
+	if err != nil {
+		return err
+	}
+	p.Content = string(b)
+	return nil
+}
+
+// AfterFind unmarshal content into lists
+func (p *Profile) AfterFind(*gorm.DB) error {
+	if p.Content == "" {
+		return nil
+	}
+	var content profileContent
+	if err := json.Unmarshal([]byte(p.Content), &content); err != nil {
+		return err
+	}
+	p.Nameservers = content.Nameservers
+	p.Fallback = content.Fallback
+	p.ProxyGroups = content.ProxyGroups
+	p.Rules = content.Rules
+	return nil
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestProfileValidate(t *testing.T) {
+	auto := ProxyGroup{Name: "Auto", Type: "url-test", URL: "http://www.gstatic.com/generate_204", Interval: 300}
+
+	cases := []struct {
+		Name    string
+		Profile Profile
+		Valid   bool
+	}{
+		{"Empty", Profile{}, true},
+		{
+			"Groups and rules",
+			Profile{
+				ProxyGroups: []ProxyGroup{auto, {Name: "Final", Type: "select", Proxies: []string{"Auto", "DIRECT"}}},
+				Rules:       []string{"DOMAIN-SUFFIX,google.com,Auto", "GEOIP,CN,DIRECT,no-resolve", "MATCH,Final"},
+			},
+			true,
+		},
+		{"Unknown group type", Profile{ProxyGroups: []ProxyGroup{{Name: "A", Type: "random"}}}, false},
+		{"Url test without url", Profile{ProxyGroups: []ProxyGroup{{Name: "A", Type: "url-test"}}}, false},
+		{"Duplicated group", Profile{ProxyGroups: []ProxyGroup{auto, auto}}, false},
+		{"Group shadowing PROXY", Profile{ProxyGroups: []ProxyGroup{{Name: "PROXY", Type: "select"}}}, false},
+		{"Unknown proxy in group", Profile{ProxyGroups: []ProxyGroup{{Name: "A", Type: "select", Proxies: []string{"B"}}}}, false},
+		{"Unknown rule type", Profile{Rules: []string{"DOMAINS,google.com,PROXY"}}, false},
+		{"Unknown rule target", Profile{Rules: []string{"GEOIP,CN,Auto"}}, false},
+		{"Match with payload", Profile{Rules: []string{"MATCH,google.com,PROXY"}}, false},
+		{"Rule with newline", Profile{Rules: []string{"MATCH,PROXY\n- MATCH,DIRECT"}}, false},
+		{"Nameservers", Profile{Nameservers: []string{"223.5.5.5", "tls://1.1.1.1:853"}, Fallback: []string{"https://1.0.0.1/dns-query"}}, true},
+		{"Nameserver with newline", Profile{Nameservers: []string{"223.5.5.5\nproxies:"}}, false},
+		{"Fallback with newline", Profile{Fallback: []string{"8.8.8.8\r\n  enable: false"}}, false},
+		{"Empty nameserver", Profile{Fallback: []string{""}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			err := c.Profile.Validate()
+			if c.Valid {
+				assert.Nil(err)
+			} else {
+				assert.NotNil(err)
+			}
+		})
+	}
+}
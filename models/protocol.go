@@ -0,0 +1,155 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/curve25519"
+)
+
+// Supported service protocols
+const (
+	ProtocolVmess       = "vmess"
+	ProtocolShadowsocks = "shadowsocks"
+	ProtocolTrojan      = "trojan"
+	ProtocolVless       = "vless"
+)
+
+// FlowVision is the only VLESS flow supported by xray at present
+const FlowVision = "xtls-rprx-vision"
+
+// validateProtocol checks stream settings and protocol specific settings
+// are a combination clients understand. Empty protocol means vmess.
+func validateProtocol(protocol string, st *StreamSettings, ss *ShadowsocksSetting, flow string) error {
+	switch protocol {
+	case "", ProtocolVmess:
+		return st.validate(false)
+	case ProtocolShadowsocks:
+		return ss.Validate()
+	case ProtocolTrojan:
+		if st.StreamSecurity != "tls" {
+			return fmt.Errorf("Trojan requires tls stream security")
+		}
+		return st.validate(false)
+	case ProtocolVless:
+		switch flow {
+		case "":
+		case FlowVision:
+			if st.TransportProtocol != "" && st.TransportProtocol != "tcp" {
+				return fmt.Errorf("Flow %s requires tcp transport", flow)
+			}
+			if st.StreamSecurity != "tls" && st.StreamSecurity != "reality" {
+				return fmt.Errorf("Flow %s requires tls or reality stream security", flow)
+			}
+		default:
+			return fmt.Errorf("Unsupported vless flow %q", flow)
+		}
+		return st.validate(true)
+	default:
+		return fmt.Errorf("Unsupported protocol %q", protocol)
+	}
+}
+
+// validate checks transport and stream security, reality is only available to vless
+func (s *StreamSettings) validate(allowReality bool) error {
+	switch s.TransportProtocol {
+	case "", "tcp", "ws", "grpc":
+	default:
+		return fmt.Errorf("Unsupported transport %q", s.TransportProtocol)
+	}
+	switch s.StreamSecurity {
+	case "", "none", "tls":
+	case "reality":
+		if !allowReality {
+			return fmt.Errorf("Reality is only supported by vless")
+		}
+		if s.TransportProtocol == "ws" {
+			return fmt.Errorf("Reality does not support ws transport")
+		}
+		if s.ServerName == "" {
+			return fmt.Errorf("Reality requires server name")
+		}
+		if s.RealityShortID != "" {
+			if _, err := hex.DecodeString(s.RealityShortID); err != nil || len(s.RealityShortID) > 16 {
+				return fmt.Errorf("Reality short id must be hex string of at most 16 characters")
+			}
+		}
+	default:
+		return fmt.Errorf("Unsupported stream security %q", s.StreamSecurity)
+	}
+	return nil
+}
+
+// Validate checks protocol settings of the service, vmess services must use
+// AEAD since xray drops alterId and clients would not agree with the node
+func (s *Service) Validate() error {
+	if s.AlterID != 0 {
+		return fmt.Errorf("Unsupported vmess alterId %d, only 0 is supported", s.AlterID)
+	}
+	return validateProtocol(s.Protocol, &s.StreamSettings, &s.ShadowsocksSetting, s.VlessUser.Flow)
+}
+
+// GenerateCredentials fills empty credentials required by the service protocol
+func (s *Service) GenerateCredentials() error {
+	switch s.Protocol {
+	case ProtocolShadowsocks:
+		if s.ShadowsocksUser.Password == "" {
+			password, err := s.ShadowsocksSetting.GeneratePassword()
+			if err != nil {
+				return err
+			}
+			s.ShadowsocksUser.Password = password
+		}
+	case ProtocolTrojan:
+		if s.TrojanUser.Password == "" {
+			password, err := randomKey(16, base64.RawURLEncoding)
+			if err != nil {
+				return fmt.Errorf("Trojan password generation error: %w", err)
+			}
+			s.TrojanUser.Password = password
+		}
+	case ProtocolVless:
+		if s.VlessUser.UUID == "" {
+			s.VlessUser.UUID = uuid.New().String()
+		}
+	}
+	return nil
+}
+
+// GenerateRealityKey generates a x25519 key pair used by reality,
+// keys are encoded the same way as `xray x25519` does
+func GenerateRealityKey() (privateKey string, publicKey string, err error) {
+	key := make([]byte, curve25519.ScalarSize)
+	if _, err := rand.Read(key); err != nil {
+		return "", "", fmt.Errorf("Reality key generation error: %w", err)
+	}
+	// Clamp the scalar as xray does
+	key[0] &= 248
+	key[31] &= 127
+	key[31] |= 64
+	pub, err := curve25519.X25519(key, curve25519.Basepoint)
+	if err != nil {
+		return "", "", fmt.Errorf("Reality key generation error: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(key), base64.RawURLEncoding.EncodeToString(pub), nil
+}
+
+// GenerateRealityShortID returns a random 8 bytes short id in hex
+func GenerateRealityShortID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("Reality short id generation error: %w", err)
+	}
+	return hex.EncodeToString(id), nil
+}
+
+func randomKey(n int, enc *base64.Encoding) (string, error) {
+	key := make([]byte, n)
+	if _, err := rand.Read(key); err != nil {
+		return "", err
+	}
+	return enc.EncodeToString(key), nil
+}
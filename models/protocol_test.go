@@ -0,0 +1,70 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+
+	assertlib "github.com/stretchr/testify/assert"
+	"golang.org/x/crypto/curve25519"
+)
+
+func TestValidateProtocol(t *testing.T) {
+	cases := []struct {
+		Name     string
+		Settings Settings
+		Valid    bool
+	}{
+		{"Empty means vmess", Settings{}, true},
+		{"Unknown protocol", Settings{Protocol: "socks"}, false},
+		{"Unknown transport", Settings{VmessSetting: VmessSetting{StreamSettings: StreamSettings{TransportProtocol: "kcp"}}}, false},
+		{"Trojan without tls", Settings{Protocol: ProtocolTrojan}, false},
+		{"Trojan with tls", Settings{Protocol: ProtocolTrojan, VmessSetting: VmessSetting{StreamSettings: StreamSettings{StreamSecurity: "tls"}}}, true},
+		{"Vmess with reality", Settings{VmessSetting: VmessSetting{StreamSettings: StreamSettings{StreamSecurity: "reality", ServerName: "example.com"}}}, false},
+		{"Vless with reality", Settings{Protocol: ProtocolVless, VlessSetting: VlessSetting{Flow: FlowVision}, VmessSetting: VmessSetting{StreamSettings: StreamSettings{StreamSecurity: "reality", ServerName: "example.com"}}}, true},
+		{"Reality without server name", Settings{Protocol: ProtocolVless, VmessSetting: VmessSetting{StreamSettings: StreamSettings{StreamSecurity: "reality"}}}, false},
+		{"Reality with invalid short id", Settings{Protocol: ProtocolVless, VmessSetting: VmessSetting{StreamSettings: StreamSettings{StreamSecurity: "reality", ServerName: "example.com", RealityShortID: "xyz"}}}, false},
+		{"Vision without tls", Settings{Protocol: ProtocolVless, VlessSetting: VlessSetting{Flow: FlowVision}}, false},
+		{"Vision over ws", Settings{Protocol: ProtocolVless, VlessSetting: VlessSetting{Flow: FlowVision}, VmessSetting: VmessSetting{StreamSettings: StreamSettings{StreamSecurity: "tls", TransportProtocol: "ws"}}}, false},
+		{"Unknown flow", Settings{Protocol: ProtocolVless, VlessSetting: VlessSetting{Flow: "xtls-rprx-direct"}}, false},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			err := c.Settings.Validate()
+			if c.Valid {
+				assertlib.Nil(t, err)
+			} else {
+				assertlib.NotNil(t, err)
+			}
+		})
+	}
+}
+
+func TestGenerateRealityKey(t *testing.T) {
+	assert := assertlib.New(t)
+	private, public, err := GenerateRealityKey()
+	assert.Nil(err)
+
+	key, err := base64.RawURLEncoding.DecodeString(private)
+	assert.Nil(err)
+	pub, err := curve25519.X25519(key, curve25519.Basepoint)
+	assert.Nil(err)
+	assert.Equal(public, base64.RawURLEncoding.EncodeToString(pub))
+
+	id, err := GenerateRealityShortID()
+	assert.Nil(err)
+	assert.Len(id, 16)
+}
+
+func TestGenerateCredentials(t *testing.T) {
+	assert := assertlib.New(t)
+
+	trojan := Service{Protocol: ProtocolTrojan}
+	assert.Nil(trojan.GenerateCredentials())
+	assert.NotEmpty(trojan.TrojanUser.Password)
+
+	vless := Service{Protocol: ProtocolVless}
+	vless.VlessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	assert.Nil(vless.GenerateCredentials())
+	assert.Equal("b831381d-6324-4d53-ad4f-8cda48b30811", vless.VlessUser.UUID)
+}
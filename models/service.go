@@ -12,10 +12,31 @@ type Service struct {
 	Protocol  string `json:"protocol"`
 	VmessUser `json:"vmessUser"`
 	VmessSetting
+	ShadowsocksUser `json:"shadowsocksUser"`
 	ShadowsocksSetting
+	TrojanUser `json:"trojanUser"`
+	VlessUser  `json:"vlessUser"`
 }
 
-type ShadowsocksSetting struct{}
+type ShadowsocksSetting struct {
+	Method     string `json:"method"`
+	ServerKey  string `json:"-"`          // server side key of 2022 methods, only given to the node config and subscriptions
+	Plugin     string `json:"plugin"`     // obfs-local or v2ray-plugin
+	PluginOpts string `json:"pluginOpts"` // SIP003 options, e.g. obfs=http;obfs-host=example.com
+}
+
+type ShadowsocksUser struct {
+	Password string `json:"password"`
+}
+
+type TrojanUser struct {
+	Password string `gorm:"column:trojan_password" json:"password"`
+}
+
+type VlessUser struct {
+	UUID string `gorm:"column:vless_uuid" json:"uuid"`
+	Flow string `json:"flow"` // empty or xtls-rprx-vision
+}
 
 type VmessUser struct {
 	Email    string `json:"email"`
@@ -30,7 +51,16 @@ type VmessSetting struct {
 }
 
 type StreamSettings struct {
-	TransportProtocol string `json:"protocol"`
+	TransportProtocol string `json:"protocol"`        // tcp, ws or grpc
+	StreamSecurity    string `json:"security"`        // none, tls or reality
+	ServerName        string `json:"serverName"`      // SNI used in tls and reality handshake
+	WSPath            string `json:"wsPath"`          // path of websocket transport
+	WSHost            string `json:"wsHost"`          // Host header of websocket transport
+	GRPCServiceName   string `json:"grpcServiceName"` // service name of grpc transport
+	AllowInsecure     bool   `json:"allowInsecure"`   // skip certificate verification on client side
+	Fingerprint       string `json:"fingerprint"`     // uTLS client fingerprint, e.g. chrome
+	RealityPublicKey  string `json:"realityPublicKey"`
+	RealityShortID    string `json:"realityShortId"`
 }
 
 type SniffingSettings struct{}
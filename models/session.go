@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/modules/utils"
+)
+
+// SessionTTL is how long a session and its refresh token last
+const SessionTTL = 24 * time.Hour
+
+// Session is a login of a user, identified by its refresh token
+type Session struct {
+	BaseModel
+	UserID     uint64    `gorm:"index" json:"-"`
+	TokenHash  string    `gorm:"uniqueIndex" json:"-"` // refresh token itself is never stored
+	UserAgent  string    `json:"user_agent"`
+	IP         string    `json:"ip"`
+	ExpireAt   time.Time `json:"expire_at"`
+	LastUsedAt time.Time `json:"last_used_at"`
+}
+
+// HashSessionToken returns what TokenHash of a refresh token is
+func HashSessionToken(token string) string {
+	return utils.Hash(token)
+}
+
+// Expired tells whether the session has expired at t
+func (s *Session) Expired(t time.Time) bool {
+	return !t.Before(s.ExpireAt)
+}
+
+// Touch records the session used at t from ip, it does not extend the
+// session since the refresh token expires at a time fixed when signed
+func (s *Session) Touch(tx *gorm.DB, t time.Time, ip string) error {
+	s.LastUsedAt = t
+	s.IP = ip
+	return tx.Model(s).UpdateColumns(map[string]interface{}{
+		"last_used_at": s.LastUsedAt,
+		"ip":           s.IP,
+	}).Error
+}
+
+// ActiveSessions scopes a session query to those not expired
+func ActiveSessions(db *gorm.DB) *gorm.DB {
+	return db.Where("expire_at > ?", time.Now())
+}
+
+// RevokeSessions logs a user out everywhere
+func RevokeSessions(tx *gorm.DB, uid uint64) error {
+	return tx.Where("user_id = ?", uid).Delete(&Session{}).Error
+}
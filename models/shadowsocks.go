@@ -0,0 +1,86 @@
+package models
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// shadowsocksMethods maps supported methods to key length in bytes,
+// 0 means the method takes a free form password
+var shadowsocksMethods = map[string]int{
+	"aes-128-gcm":                   0,
+	"aes-256-gcm":                   0,
+	"chacha20-ietf-poly1305":        0,
+	"xchacha20-ietf-poly1305":       0,
+	"2022-blake3-aes-128-gcm":       16,
+	"2022-blake3-aes-256-gcm":       32,
+	"2022-blake3-chacha20-poly1305": 32,
+}
+
+var shadowsocksPlugins = map[string]bool{
+	"":             true,
+	"obfs-local":   true,
+	"v2ray-plugin": true,
+}
+
+// Validate checks method and plugin are supported
+func (s *ShadowsocksSetting) Validate() error {
+	if _, ok := shadowsocksMethods[s.Method]; !ok {
+		return fmt.Errorf("Unsupported shadowsocks method %q", s.Method)
+	}
+	if !shadowsocksPlugins[s.Plugin] {
+		return fmt.Errorf("Unsupported shadowsocks plugin %q", s.Plugin)
+	}
+	return nil
+}
+
+// Is2022 tells whether the method is one of shadowsocks 2022 edition
+func (s *ShadowsocksSetting) Is2022() bool {
+	return strings.HasPrefix(s.Method, "2022-")
+}
+
+// GeneratePassword returns a random password suitable for the method,
+// 2022 methods require a base64 encoded key of exact length
+func (s *ShadowsocksSetting) GeneratePassword() (string, error) {
+	n := shadowsocksMethods[s.Method]
+	if n == 0 {
+		n = 16
+	}
+	key := make([]byte, n)
+	if _, err := rand.Read(key); err != nil {
+		return "", fmt.Errorf("Shadowsocks password generation error: %w", err)
+	}
+	if s.Is2022() {
+		return base64.StdEncoding.EncodeToString(key), nil
+	}
+	return base64.RawURLEncoding.EncodeToString(key), nil
+}
+
+// ParsePluginOpts splits SIP003 plugin options into a map,
+// options without value such as tls are mapped to empty string
+func (s *ShadowsocksSetting) ParsePluginOpts() map[string]string {
+	opts := make(map[string]string)
+	for _, opt := range strings.Split(s.PluginOpts, ";") {
+		if opt == "" {
+			continue
+		}
+		kv := strings.SplitN(opt, "=", 2)
+		if len(kv) == 1 {
+			opts[kv[0]] = ""
+			continue
+		}
+		opts[kv[0]] = kv[1]
+	}
+	return opts
+}
+
+// ShadowsocksPassword returns the password clients should use,
+// multi user 2022 methods join server key and user key with a colon
+func (s *Service) ShadowsocksPassword() string {
+	if s.ShadowsocksSetting.Is2022() && s.ServerKey != "" {
+		return s.ServerKey + ":" + s.ShadowsocksUser.Password
+	}
+	return s.ShadowsocksUser.Password
+}
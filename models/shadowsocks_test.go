@@ -0,0 +1,67 @@
+package models
+
+import (
+	"encoding/base64"
+	"testing"
+
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestShadowsocksGeneratePassword(t *testing.T) {
+	cases := []struct {
+		Method string
+		KeyLen int // 0 means not a base64 key
+	}{
+		{"aes-128-gcm", 0},
+		{"chacha20-ietf-poly1305", 0},
+		{"2022-blake3-aes-128-gcm", 16},
+		{"2022-blake3-aes-256-gcm", 32},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Method, func(t *testing.T) {
+			assert := assertlib.New(t)
+			ss := ShadowsocksSetting{Method: c.Method}
+			assert.Nil(ss.Validate())
+
+			password, err := ss.GeneratePassword()
+			assert.Nil(err)
+			assert.NotEmpty(password)
+			if c.KeyLen != 0 {
+				key, err := base64.StdEncoding.DecodeString(password)
+				assert.Nil(err)
+				assert.Len(key, c.KeyLen)
+			}
+		})
+	}
+}
+
+func TestShadowsocksValidate(t *testing.T) {
+	assert := assertlib.New(t)
+	assert.NotNil((&ShadowsocksSetting{Method: "rc4-md5"}).Validate())
+	assert.NotNil((&ShadowsocksSetting{Method: "aes-128-gcm", Plugin: "kcptun"}).Validate())
+	assert.Nil((&ShadowsocksSetting{Method: "aes-128-gcm", Plugin: "obfs-local"}).Validate())
+}
+
+func TestShadowsocksParsePluginOpts(t *testing.T) {
+	assert := assertlib.New(t)
+	ss := ShadowsocksSetting{PluginOpts: "tls;host=example.com;path=/ray;"}
+	assert.Equal(map[string]string{
+		"tls":  "",
+		"host": "example.com",
+		"path": "/ray",
+	}, ss.ParsePluginOpts())
+}
+
+func TestShadowsocksPassword(t *testing.T) {
+	assert := assertlib.New(t)
+
+	var s Service
+	s.Method = "aes-128-gcm"
+	s.ServerKey = "server"
+	s.ShadowsocksUser.Password = "user"
+	assert.Equal("user", s.ShadowsocksPassword())
+
+	s.Method = "2022-blake3-aes-128-gcm"
+	assert.Equal("server:user", s.ShadowsocksPassword())
+}
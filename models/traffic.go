@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// TrafficReport records a batch of traffic reported by a node
+type TrafficReport struct {
+	BaseModel
+	NodeID   uint64 `json:"nid"`
+	Services uint   `json:"services"` // number of services in the batch
+	Upload   uint64 `json:"upload"`
+	Download uint64 `json:"download"`
+}
+
+// TrafficLog accumulates traffic of a service on a node within an hour
+type TrafficLog struct {
+	BaseModel
+	UserID    uint64    `gorm:"uniqueIndex:idx_traffic_log;index" json:"uid"`
+	ServiceID uint64    `gorm:"uniqueIndex:idx_traffic_log" json:"sid"`
+	NodeID    uint64    `gorm:"uniqueIndex:idx_traffic_log;index" json:"nid"`
+	Hour      time.Time `gorm:"uniqueIndex:idx_traffic_log" json:"hour"` // start of the hour in UTC
+	Upload    uint64    `json:"upload"`
+	Download  uint64    `json:"download"`
+	Unknown   uint64    `json:"unknown"` // traffic of the legacy endpoint which does not tell upload from download
+}
+
+// TrafficCycle archives traffic used by a user or a node in a billing cycle,
+// exactly one of UserID and NodeID is set
+type TrafficCycle struct {
+	BaseModel
+	UserID  uint64    `gorm:"index" json:"uid"`
+	NodeID  uint64    `gorm:"index" json:"nid"`
+	Start   time.Time `json:"start"`
+	End     time.Time `json:"end"`
+	Traffic int64     `json:"traffic"`
+	Max     int64     `json:"max"` // quota in the cycle
+}
+
+// Traffic history intervals
+const (
+	IntervalHour = "hour"
+	IntervalDay  = "day"
+)
+
+// TrafficPoint is the traffic within an interval starting at Time
+type TrafficPoint struct {
+	Time     time.Time `json:"time"`
+	Upload   uint64    `json:"upload"`
+	Download uint64    `json:"download"`
+	Unknown  uint64    `json:"unknown"`
+}
+
+// AddTrafficLog adds traffic to the log of the hour t is in,
+// the log is created if it does not exist yet
+func AddTrafficLog(tx *gorm.DB, uid, sid, nid uint64, t time.Time, upload, download uint64) error {
+	return addTrafficLog(tx, TrafficLog{
+		UserID:    uid,
+		ServiceID: sid,
+		NodeID:    nid,
+		Hour:      t.UTC().Truncate(time.Hour),
+		Upload:    upload,
+		Download:  download,
+	})
+}
+
+// AddUnknownTrafficLog is AddTrafficLog for traffic not split into upload
+// and download, it is kept apart so it does not inflate either
+func AddUnknownTrafficLog(tx *gorm.DB, uid, sid, nid uint64, t time.Time, traffic uint64) error {
+	return addTrafficLog(tx, TrafficLog{
+		UserID:    uid,
+		ServiceID: sid,
+		NodeID:    nid,
+		Hour:      t.UTC().Truncate(time.Hour),
+		Unknown:   traffic,
+	})
+}
+
+func addTrafficLog(tx *gorm.DB, log TrafficLog) error {
+	return tx.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "user_id"}, {Name: "service_id"}, {Name: "node_id"}, {Name: "hour"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"upload":     gorm.Expr("upload + ?", log.Upload),
+			"download":   gorm.Expr("download + ?", log.Download),
+			"unknown":    gorm.Expr("unknown + ?", log.Unknown),
+			"updated_at": time.Now(),
+		}),
+	}).Create(&log).Error
+}
+
+// TrafficSum sums up upload and download of logs matched by query since from,
+// query should be a scoped db such as db.Where("user_id = ?", uid)
+func TrafficSum(query *gorm.DB, from time.Time) (upload, download uint64, err error) {
+	var sum struct {
+		Upload   uint64
+		Download uint64
+	}
+	err = query.Model(&TrafficLog{}).
+		Select("COALESCE(SUM(upload), 0) AS upload, COALESCE(SUM(download), 0) AS download").
+		Where("hour >= ?", from.UTC()).Scan(&sum).Error
+	return sum.Upload, sum.Download, err
+}
+
+// TrafficHistory sums up logs matched by query between from and to by interval,
+// query should be a scoped db such as db.Where("user_id = ?", uid)
+func TrafficHistory(query *gorm.DB, from, to time.Time, interval string) ([]TrafficPoint, error) {
+	// Logs are summed in database so long ranges are not loaded row by row,
+	// DATE works on both sqlite and mysql
+	var bucket string
+	switch interval {
+	case IntervalHour:
+		bucket = "hour"
+	case IntervalDay:
+		bucket = "DATE(hour)"
+	default:
+		return nil, fmt.Errorf("Invalid interval %q", interval)
+	}
+
+	var rows []struct {
+		Bucket   string
+		Upload   uint64
+		Download uint64
+		Unknown  uint64
+	}
+	if err := query.Model(&TrafficLog{}).
+		Select(bucket+" AS bucket, SUM(upload) AS upload, SUM(download) AS download, SUM(unknown) AS unknown").
+		Where("hour >= ? AND hour < ?", from.UTC(), to.UTC()).
+		Group(bucket).Order(bucket).Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	history := make([]TrafficPoint, 0, len(rows))
+	for _, r := range rows {
+		t, err := parseBucket(r.Bucket)
+		if err != nil {
+			return nil, err
+		}
+		history = append(history, TrafficPoint{
+			Time:     t,
+			Upload:   r.Upload,
+			Download: r.Download,
+			Unknown:  r.Unknown,
+		})
+	}
+	return history, nil
+}
+
+// parseBucket parses a bucket of TrafficHistory, drivers give hours as time
+// or text and days as text
+func parseBucket(s string) (time.Time, error) {
+	for _, layout := range []string{time.RFC3339Nano, "2006-01-02 15:04:05", "2006-01-02"} {
+		if t, err := time.ParseInLocation(layout, s, time.UTC); err == nil {
+			return t.UTC(), nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("Invalid traffic history time %q", s)
+}
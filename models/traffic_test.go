@@ -0,0 +1,44 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	orm "github.com/coolray-dev/raydash/database"
+	assertlib "github.com/stretchr/testify/assert"
+	"gorm.io/gorm"
+)
+
+func TestTrafficHistory(t *testing.T) {
+	assert := assertlib.New(t)
+	tx := orm.DB.Begin()
+	defer tx.Rollback()
+
+	// Logs of a node no other test uses
+	const nid = 1 << 40
+	day := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	assert.Nil(AddTrafficLog(tx, 1, 1, nid, day.Add(10*time.Hour), 1, 2))
+	assert.Nil(AddTrafficLog(tx, 1, 1, nid, day.Add(11*time.Hour+30*time.Minute), 10, 20))
+	assert.Nil(AddTrafficLog(tx, 2, 2, nid, day.Add(11*time.Hour), 100, 200))
+	assert.Nil(AddUnknownTrafficLog(tx, 2, 2, nid, day.Add(25*time.Hour), 1000))
+	assert.Nil(AddTrafficLog(tx, 2, 2, nid, day.Add(-time.Hour), 5, 5)) // before the range
+	query := tx.Where("node_id = ?", nid)
+
+	hourly, err := TrafficHistory(query.Session(&gorm.Session{}), day, day.AddDate(0, 0, 2), IntervalHour)
+	assert.Nil(err)
+	assert.Equal([]TrafficPoint{
+		{Time: day.Add(10 * time.Hour), Upload: 1, Download: 2},
+		{Time: day.Add(11 * time.Hour), Upload: 110, Download: 220},
+		{Time: day.Add(25 * time.Hour), Unknown: 1000},
+	}, hourly)
+
+	daily, err := TrafficHistory(query.Session(&gorm.Session{}), day, day.AddDate(0, 0, 2), IntervalDay)
+	assert.Nil(err)
+	assert.Equal([]TrafficPoint{
+		{Time: day, Upload: 111, Download: 222},
+		{Time: day.AddDate(0, 0, 1), Unknown: 1000},
+	}, daily)
+
+	_, err = TrafficHistory(query.Session(&gorm.Session{}), day, day.AddDate(0, 0, 2), "week")
+	assert.NotNil(err)
+}
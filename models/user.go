@@ -2,7 +2,6 @@ package models
 
 import (
 	"crypto/rand"
-	"encoding/json"
 	"fmt"
 	"time"
 
@@ -13,19 +12,27 @@ import (
 // User table model
 type User struct {
 	BaseModel
-	UUID              string               `gorm:"unique" json:"uuid" fake:"{uuid}"`
-	Token             map[string]time.Time `json:"-" gorm:"-" fake:"skip"`            // gorm doesn't support complex type so hav to marshal it
-	TokenStr          string               `json:"-" gorm:"column:token" fake:"skip"` // the actual data is stored here
-	JwtKey            []byte               `json:"-" fake:"skip"`                     // Do not export it due to leak risk
-	Email             string               `gorm:"unique" json:"email" fake:"{email}"`
-	Username          string               `gorm:"unique" json:"username" fake:"{username}"`
-	Password          string               `json:"-" fake:"{password:true,true,true,true,true,8}"`
-	SubscriptionToken string               `json:"subscription_token"`
-	CurrentTraffic    int64                `json:"current_traffic"`
-	MaxTraffic        int64                `json:"max_traffic"`
-	Groups            []*Group             `gorm:"many2many:groups_users;" json:"-" fake:"skip"`
+	UUID              string     `gorm:"unique" json:"uuid" fake:"{uuid}"`
+	JwtKey            []byte     `json:"-" fake:"skip"` // Do not export it due to leak risk
+	Email             string     `gorm:"unique" json:"email" fake:"{email}"`
+	Username          string     `gorm:"unique" json:"username" fake:"{username}"`
+	Password          string     `json:"-" fake:"{password:true,true,true,true,true,8}"`
+	SubscriptionToken string     `json:"subscription_token"`
+	CurrentTraffic    int64      `json:"current_traffic"`
+	MaxTraffic        int64      `json:"max_traffic"`
+	ExpireAt          *time.Time `json:"expire_at" fake:"skip"`
+	Status            string     `gorm:"default:active" json:"status" fake:"skip"`
+	LastResetAt       *time.Time `json:"last_reset_at" fake:"skip"` // start of current traffic cycle, nil until the scheduler first runs
+	Groups            []*Group   `gorm:"many2many:groups_users;" json:"-" fake:"skip"`
+	TOTP
 }
 
+// User status
+const (
+	UserActive    = "active"
+	UserSuspended = "suspended" // quota used up or plan expired
+)
+
 // GetJwtKey provide access to private var jwtKey, if jwtKey is nil then generate it
 func (user *User) GetJwtKey() (key []byte, err error) {
 	if user.JwtKey == nil {
@@ -43,27 +50,70 @@ func (user *User) GetJwtKey() (key []byte, err error) {
 	return key, nil
 }
 
-// BeforeSave marshal the token map
-// This is a GORM feature called hook
-func (user *User) BeforeSave(*gorm.DB) error {
-	if user.Token == nil {
-		return nil
-	}
+// Exceeded tells whether the user has used up the quota, MaxTraffic not above 0 means unlimited
+func (user *User) Exceeded() bool {
+	return user.MaxTraffic > 0 && user.CurrentTraffic >= user.MaxTraffic
+}
 
-	b, err := json.Marshal(&user.Token)
-	if err != nil {
-		return err
+// CycleStart returns the start of the current traffic cycle of user,
+// CreatedAt before the scheduler has set it
+func (user *User) CycleStart() time.Time {
+	if user.LastResetAt != nil {
+		return *user.LastResetAt
 	}
+	return user.CreatedAt
+}
 
-	user.TokenStr = string(b)
-	return nil
+// Expired tells whether the plan of user has expired at t
+func (user *User) Expired(t time.Time) bool {
+	return user.ExpireAt != nil && !t.Before(*user.ExpireAt)
+}
+
+// Enforce suspends the user who exceeded quota or expired, and restores
+// the user otherwise. It reports whether status changed.
+func (user *User) Enforce(t time.Time) bool {
+	status := UserActive
+	if user.Exceeded() || user.Expired(t) {
+		status = UserSuspended
+	}
+	changed := user.Status != status
+	user.Status = status
+	return changed
 }
 
-// AfterFind unmarshal token map
-func (user *User) AfterFind(*gorm.DB) error {
-	if user.TokenStr == "" {
+// EnforceUsers runs Enforce on users of ids and saves those changed
+func EnforceUsers(tx *gorm.DB, ids []uint64) error {
+	if len(ids) == 0 {
 		return nil
 	}
+	var users []User
+	if err := tx.Find(&users, ids).Error; err != nil {
+		return err
+	}
+	now := time.Now()
+	for i := range users {
+		if !users[i].Enforce(now) {
+			continue
+		}
+		if err := tx.Model(&users[i]).UpdateColumn("status", users[i].Status).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Active tells whether the user is not suspended at t
+func (user *User) Active(t time.Time) bool {
+	return user.Status != UserSuspended && !user.Expired(t)
+}
+
+// ActiveUsers scopes a user query to those neither suspended nor expired,
+// expiry is checked here too since nothing may have enforced it yet
+func ActiveUsers(db *gorm.DB) *gorm.DB {
+	return db.Where("status <> ? AND (expire_at IS NULL OR expire_at > ?)", UserSuspended, time.Now())
+}
 
-	return json.Unmarshal([]byte(user.TokenStr), &user.Token)
+// ActiveServices scopes a service query to those of active users
+func ActiveServices(db *gorm.DB) *gorm.DB {
+	return db.Where("user_id IN (?)", orm.DB.Model(&User{}).Select("id").Scopes(ActiveUsers))
 }
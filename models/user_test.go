@@ -0,0 +1,38 @@
+package models
+
+import (
+	"testing"
+	"time"
+
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestUserEnforce(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	cases := []struct {
+		Name   string
+		User   User
+		Status string
+	}{
+		{"Unlimited", User{CurrentTraffic: 100}, UserActive},
+		{"Within quota", User{CurrentTraffic: 100, MaxTraffic: 200}, UserActive},
+		{"Quota used up", User{CurrentTraffic: 200, MaxTraffic: 200}, UserSuspended},
+		{"Expired", User{ExpireAt: &past}, UserSuspended},
+		{"Not expired", User{ExpireAt: &future}, UserActive},
+		{"Quota raised", User{Status: UserSuspended, CurrentTraffic: 200, MaxTraffic: 300}, UserActive},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			changed := c.User.Enforce(now)
+			assert.Equal(c.Status, c.User.Status)
+			assert.True(changed)
+			assert.False(c.User.Enforce(now))
+			assert.Equal(c.Status == UserActive, c.User.Active(now))
+		})
+	}
+}
@@ -44,24 +44,30 @@ func addPolicies() {
 	basicRules := [][]string{
 		{"group::admin", "/*", ".*"},
 		{"role::anonymous", "/*/swagger/.*", ".*"},
-		{"role::anonymous", "/*/login", "POST"},
+		{"role::anonymous", "/*/login(/mfa(/enroll)?)?$", "POST"},
 		{"role::anonymous", "/*/register", "POST"},
 		{"role::anonymous", "/*/refresh", "POST"},
 		{"role::anonymous", "/*/password/.*", "POST"},
+		{"role::anonymous", "/*/subscription/.*", "GET"},
+		{"role::anonymous", "^/metrics$", "GET"}, // guarded by metrics token or allowlist
 	}
 	Enforcer.AddPolicies(basicRules)
 
 	// Add group policies
 	var groups []models.Group
-	if err := database.DB.Preload("Users").Find(&groups).Error; err != nil {
+	if err := database.DB.Preload("Users").Preload("Nodes").Find(&groups).Error; err != nil {
 		log.Log.WithError(err).Error()
 	}
-	for _, g := range groups {
-		Enforcer.AddPolicy("group::"+g.Name, "/*/groups/"+strconv.Itoa(int(g.ID))+".*", "*")
+	for i := range groups {
+		g := &groups[i]
+		AddGroupPolicy(g)
 		for _, u := range g.Users {
 			// Add user to group
 			Enforcer.AddGroupingPolicy(u.Username, "group::"+g.Name)
 		}
+		for _, n := range g.Nodes {
+			AddGroupNodePolicy(g, n)
+		}
 	}
 
 	// Add user policies
@@ -79,9 +85,7 @@ func addPolicies() {
 		log.Log.WithError(err).Error()
 	}
 	for _, n := range nodes {
-		Enforcer.AddPolicy("node::"+strconv.Itoa(int(n.ID)),
-			"/*/nodes/"+strconv.Itoa(int(n.ID))+".*",
-			".*")
+		AddNodePolicy(&n)
 	}
 
 	// Explicitly trigger save policies
@@ -93,13 +97,16 @@ func AddDefaultUserPolicy(u *models.User) {
 	Enforcer.AddPolicy(u.Username, "/*/announcements.*", "GET")
 	Enforcer.AddPolicy(u.Username, "/*/logout", "DELETE")
 	Enforcer.AddPolicy(u.Username, "/*/users/"+u.Username+"$", ".*")
-	Enforcer.AddPolicy(u.Username, "/*/users/"+u.Username+"/(groups|services|nodes)$", "GET")
+	Enforcer.AddPolicy(u.Username, "/*/users/"+u.Username+"/(groups|services|nodes|traffic/history)$", "GET")
+	Enforcer.AddPolicy(u.Username, "/*/users/"+u.Username+"/totp(/recovery)?$", "POST|PUT|DELETE")
+	Enforcer.AddPolicy(u.Username, "/*/users/"+u.Username+"/password$", "PUT")
+	Enforcer.AddPolicy(u.Username, "/*/users/"+u.Username+"/sessions(/[0-9]+)?$", "GET|DELETE")
 	Enforcer.AddPolicy(u.Username, "/*/nodes$", "GET")
 
 	// Add policy for owned services
 	var services []models.Service
 	if err := database.DB.
-		Where("uid = ?", u.ID).Find(&services).Error; err != nil {
+		Where("user_id = ?", u.ID).Find(&services).Error; err != nil {
 		log.Log.WithError(err).Error()
 	}
 
@@ -109,3 +116,63 @@ func AddDefaultUserPolicy(u *models.User) {
 			".*")
 	}
 }
+
+// AddNodePolicy allows a node to reach the endpoints its agent uses and
+// nothing else, so a leaked node token could not reassign groups or post
+// announcements. Paths are anchored so node 1 could not reach node 10.
+func AddNodePolicy(n *models.Node) {
+	sub := "node::" + strconv.Itoa(int(n.ID))
+	path := "/*/nodes/" + strconv.Itoa(int(n.ID))
+	Enforcer.AddPolicy(sub, path+"/(config|services|users|traffic|heartbeat)$", ".*")
+	Enforcer.AddPolicy(sub, path+"/users/[^/]+/traffic$", "PATCH") // legacy absolute traffic
+}
+
+// AddGroupPolicy allows members of a group to read it
+func AddGroupPolicy(g *models.Group) {
+	Enforcer.AddPolicy("group::"+g.Name, groupObject(g), "GET")
+}
+
+// RemoveGroupPolicy revokes what AddGroupPolicy grants
+func RemoveGroupPolicy(g *models.Group) {
+	Enforcer.RemovePolicy("group::"+g.Name, groupObject(g), "GET")
+}
+
+// RemoveGroup revokes every policy of a group and takes its members out,
+// it should be called after the group is deleted
+func RemoveGroup(g *models.Group) {
+	Enforcer.RemoveFilteredPolicy(0, "group::"+g.Name)
+	Enforcer.RemoveFilteredGroupingPolicy(1, "group::"+g.Name)
+}
+
+// RenameGroup moves policies and members of a group from its old name
+// to the current name of g
+func RenameGroup(old string, g *models.Group) {
+	policies := Enforcer.GetFilteredPolicy(0, "group::"+old)
+	members := Enforcer.GetFilteredGroupingPolicy(1, "group::"+old)
+	RemoveGroup(&models.Group{Name: old})
+	for _, p := range policies {
+		p[0] = "group::" + g.Name
+		Enforcer.AddPolicy(p)
+	}
+	for _, m := range members {
+		Enforcer.AddGroupingPolicy(m[0], "group::"+g.Name)
+	}
+}
+
+func groupObject(g *models.Group) string {
+	return "/*/groups/" + strconv.Itoa(int(g.ID)) + "(/.*)?$"
+}
+
+// AddGroupNodePolicy allows members of a group to see a node of the group
+func AddGroupNodePolicy(g *models.Group, n *models.Node) {
+	Enforcer.AddPolicy("group::"+g.Name, groupNodeObject(n), "GET")
+}
+
+// RemoveGroupNodePolicy revokes what AddGroupNodePolicy grants
+func RemoveGroupNodePolicy(g *models.Group, n *models.Node) {
+	Enforcer.RemovePolicy("group::"+g.Name, groupNodeObject(n), "GET")
+}
+
+func groupNodeObject(n *models.Node) string {
+	return "/*/nodes/" + strconv.Itoa(int(n.ID)) + "$"
+}
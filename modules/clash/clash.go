@@ -1,52 +1,279 @@
 package clash
 
 import (
-	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sync"
 	"text/template"
 
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/subscription"
 	"github.com/coolray-dev/raydash/modules/utils"
+)
 
-	"github.com/coolray-dev/raydash/models"
+func init() {
+	subscription.Register("clash", &Renderer{
+		TemplatePath: utils.AbsPath("template/clash.tmpl"),
+	})
+}
+
+// Used when user has no profile or the profile leaves them empty
+var (
+	defaultNameservers = []string{
+		"https://doh.rixcloud.dev/dns-query",
+		"https://dns.alidns.com/dns-query",
+		"https://dns.pub/dns-query",
+	}
+	defaultFallback = []string{
+		"https://doh.dns.sb/dns-query",
+		"https://dns.google/dns-query",
+		"https://1.1.1.1/dns-query",
+	}
 )
 
+type clashConfig struct {
+	Nameservers []string
+	Fallback    []string
+	Proxies     []clashNode
+	ProxyGroups []models.ProxyGroup // extra groups besides PROXY
+	Rules       []string
+}
+
+// clashNode is a clash proxy, it is written in json flow style which is valid yaml
 type clashNode struct {
-	Name      string
-	Type      string
-	Server    string
-	Port      uint
-	UUID      string
-	AlterID   uint `yaml:"alterId"`
-	Cipher    string
-	UDP       bool
-	Network   string
-	WSPath    string
-	WSHeaders map[string]string
-}
-
-func Generate(services []*models.Service, b *bytes.Buffer) string {
-	var nodeYAML []clashNode
-	for _, s := range services {
-		nodeYAML = append(nodeYAML, *convert(s))
-	}
-	tmpl, err := template.ParseFiles(utils.AbsPath("template/clash.tmpl"))
+	Name           string       `json:"name"`
+	Type           string       `json:"type"`
+	Server         string       `json:"server"`
+	Port           uint         `json:"port"`
+	UUID           string       `json:"uuid,omitempty"`
+	Flow           string       `json:"flow,omitempty"`
+	AlterID        *uint        `json:"alterId,omitempty"` // pointer since vmess requires it even if 0
+	Cipher         string       `json:"cipher,omitempty"`
+	Password       string       `json:"password,omitempty"`
+	Plugin         string       `json:"plugin,omitempty"`
+	PluginOpts     pluginOpts   `json:"plugin-opts,omitempty"`
+	UDP            bool         `json:"udp"`
+	TLS            bool         `json:"tls,omitempty"`
+	ServerName     string       `json:"servername,omitempty"` // vmess and vless
+	SNI            string       `json:"sni,omitempty"`        // trojan
+	SkipCertVerify bool         `json:"skip-cert-verify,omitempty"`
+	Fingerprint    string       `json:"client-fingerprint,omitempty"`
+	RealityOpts    *realityOpts `json:"reality-opts,omitempty"`
+	Network        string       `json:"network,omitempty"`
+	WSOpts         *wsOpts      `json:"ws-opts,omitempty"`
+	GRPCOpts       *grpcOpts    `json:"grpc-opts,omitempty"`
+}
+
+type wsOpts struct {
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type pluginOpts map[string]interface{}
+
+type realityOpts struct {
+	PublicKey string `json:"public-key"`
+	ShortID   string `json:"short-id,omitempty"`
+}
+
+type grpcOpts struct {
+	ServiceName string `json:"grpc-service-name"`
+}
+
+// Renderer renders subscriptions into clash config
+type Renderer struct {
+	TemplatePath string
+
+	once sync.Once
+	tmpl *template.Template
+	err  error
+}
+
+// ContentType implements subscription.Renderer
+func (r *Renderer) ContentType() string {
+	return "text/yaml; charset=utf-8"
+}
+
+// Render implements subscription.Renderer
+func (r *Renderer) Render(w io.Writer, sub *subscription.Subscription) error {
+	tmpl, err := r.template()
 	if err != nil {
-		panic(err)
+		return err
 	}
-	tmpl.Execute(b, nodeYAML)
-	return ""
+
+	cfg := clashConfig{
+		Nameservers: defaultNameservers,
+		Fallback:    defaultFallback,
+	}
+	if p := sub.Profile; p != nil {
+		if len(p.Nameservers) > 0 {
+			cfg.Nameservers = p.Nameservers
+		}
+		if len(p.Fallback) > 0 {
+			cfg.Fallback = p.Fallback
+		}
+		cfg.ProxyGroups = p.ProxyGroups
+		cfg.Rules = p.Rules
+	}
+	// Proxies and groups share names, GLOBAL is built into clash
+	reserved := []string{models.DefaultProxyGroup, "DIRECT", "REJECT", "GLOBAL"}
+	for _, g := range cfg.ProxyGroups {
+		reserved = append(reserved, g.Name)
+	}
+	names := subscription.NewNames(reserved...)
+	for _, s := range sub.Services {
+		node, err := convert(s, sub.Nodes[s.NodeID])
+		if err != nil {
+			return err
+		}
+		node.Name = names.Unique(node.Name)
+		cfg.Proxies = append(cfg.Proxies, *node)
+	}
+	if err := tmpl.Execute(w, &cfg); err != nil {
+		return fmt.Errorf("Error executing clash template: %w", err)
+	}
+	return nil
+}
+
+// template parses the template file only once and caches the result
+func (r *Renderer) template() (*template.Template, error) {
+	r.once.Do(func() {
+		r.tmpl, r.err = template.New(filepath.Base(r.TemplatePath)).
+			Funcs(template.FuncMap{"quote": quote, "json": toJSON}).
+			ParseFiles(r.TemplatePath)
+		if r.err != nil {
+			r.err = fmt.Errorf("Error parsing clash template: %w", r.err)
+		}
+	})
+	return r.tmpl, r.err
+}
+
+// quote returns a double quoted yaml string, json strings are valid yaml
+func quote(s string) string {
+	b, _ := json.Marshal(s)
+	return string(b)
+}
+
+func toJSON(v interface{}) (string, error) {
+	b, err := json.Marshal(v)
+	return string(b), err
 }
 
-func convert(s *models.Service) *clashNode {
+// convert builds a clash proxy from service and the node it belongs to,
+// node could be nil
+func convert(s *models.Service, n *models.Node) (*clashNode, error) {
 	var node clashNode
 	node.Name = s.Name
-	node.Type = "vmess"
 	node.Server = s.Host
 	node.Port = s.Port
-	node.UUID = s.UUID
-	node.AlterID = s.AlterID
-	node.Cipher = "auto"
-	node.UDP = true
-	node.Network = s.TransportProtocol
-	node.WSPath = "/"
-	return &node
+	if n != nil {
+		node.UDP = n.HasUDP
+	}
+
+	switch s.Protocol {
+	case "", models.ProtocolVmess:
+		node.Type = "vmess"
+		node.UUID = s.VmessUser.UUID
+		alterID := s.AlterID
+		node.AlterID = &alterID
+		node.Cipher = s.VmessUser.Security
+		if node.Cipher == "" {
+			node.Cipher = "auto"
+		}
+	case models.ProtocolVless:
+		node.Type = "vless"
+		node.UUID = s.VlessUser.UUID
+		node.Flow = s.Flow
+	case models.ProtocolTrojan:
+		node.Type = "trojan"
+		node.Password = s.TrojanUser.Password
+	case models.ProtocolShadowsocks:
+		node.Type = "ss"
+		node.Cipher = s.Method
+		node.Password = s.ShadowsocksPassword()
+		if err := convertPlugin(&node, &s.ShadowsocksSetting); err != nil {
+			return nil, fmt.Errorf("Service %d: %w", s.ID, err)
+		}
+
+		// Shadowsocks has no stream settings
+		return &node, nil
+	default:
+		return nil, fmt.Errorf("Protocol %s of service %d not supported by clash renderer", s.Protocol, s.ID)
+	}
+
+	switch s.StreamSecurity {
+	case "", "none":
+	case "tls":
+		node.SkipCertVerify = s.AllowInsecure
+		if node.Type == "trojan" {
+			node.SNI = s.ServerName
+		} else {
+			node.TLS = true
+			node.ServerName = s.ServerName
+		}
+		node.Fingerprint = s.Fingerprint
+	case "reality":
+		if node.Type != "vless" {
+			return nil, fmt.Errorf("Reality of service %d requires vless", s.ID)
+		}
+		node.TLS = true
+		node.ServerName = s.ServerName
+		node.Fingerprint = s.Fingerprint
+		if node.Fingerprint == "" {
+			node.Fingerprint = "chrome" // reality requires a client fingerprint
+		}
+		node.RealityOpts = &realityOpts{
+			PublicKey: s.RealityPublicKey,
+			ShortID:   s.RealityShortID,
+		}
+	default:
+		return nil, fmt.Errorf("Stream security %s of service %d not supported by clash renderer", s.StreamSecurity, s.ID)
+	}
+
+	switch s.TransportProtocol {
+	case "", "tcp":
+	case "ws":
+		node.Network = "ws"
+		node.WSOpts = &wsOpts{Path: s.WSPath}
+		if node.WSOpts.Path == "" {
+			node.WSOpts.Path = "/"
+		}
+		if s.WSHost != "" {
+			node.WSOpts.Headers = map[string]string{"Host": s.WSHost}
+		}
+	case "grpc":
+		node.Network = "grpc"
+		node.GRPCOpts = &grpcOpts{ServiceName: s.GRPCServiceName}
+	default:
+		return nil, fmt.Errorf("Transport %s of service %d not supported by clash renderer", s.TransportProtocol, s.ID)
+	}
+	return &node, nil
+}
+
+// convertPlugin translates SIP003 plugin options into clash plugin-opts
+func convertPlugin(node *clashNode, ss *models.ShadowsocksSetting) error {
+	opts := ss.ParsePluginOpts()
+	switch ss.Plugin {
+	case "":
+	case "obfs-local":
+		node.Plugin = "obfs"
+		node.PluginOpts = pluginOpts{
+			"mode": opts["obfs"],
+			"host": opts["obfs-host"],
+		}
+	case "v2ray-plugin":
+		node.Plugin = "v2ray-plugin"
+		_, tls := opts["tls"]
+		node.PluginOpts = pluginOpts{
+			"mode": "websocket",
+			"tls":  tls,
+			"host": opts["host"],
+			"path": opts["path"],
+		}
+	default:
+		return fmt.Errorf("Shadowsocks plugin %s not supported by clash renderer", ss.Plugin)
+	}
+	return nil
 }
@@ -0,0 +1,207 @@
+package clash
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/subscription"
+	"github.com/coolray-dev/raydash/modules/utils"
+	assertlib "github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v2"
+)
+
+func TestConvert(t *testing.T) {
+	node := models.Node{HasUDP: true}
+
+	ws := models.Service{Name: "ws", Host: "example.com", Port: 443, Protocol: "vmess"}
+	ws.VmessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	ws.TransportProtocol = "ws"
+	ws.WSHost = "cdn.example.com"
+	ws.StreamSecurity = "tls"
+	ws.ServerName = "example.com"
+	ws.AllowInsecure = true
+
+	trojan := models.Service{Name: "trojan", Host: "example.com", Port: 443, Protocol: "trojan"}
+	trojan.TransportProtocol = "grpc"
+	trojan.GRPCServiceName = "raydash"
+	trojan.StreamSecurity = "tls"
+	trojan.ServerName = "example.com"
+	trojan.TrojanUser.Password = "password"
+
+	vless := models.Service{Name: "vless", Host: "example.com", Port: 443, Protocol: "vless"}
+	vless.VlessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	vless.Flow = models.FlowVision
+	vless.StreamSecurity = "reality"
+	vless.ServerName = "www.example.com"
+	vless.RealityPublicKey = "public"
+	vless.RealityShortID = "6ba85179e30d4fc2"
+
+	ss := models.Service{Name: "ss", Host: "example.com", Port: 8388, Protocol: "shadowsocks"}
+	ss.Method = "aes-128-gcm"
+	ss.ShadowsocksUser.Password = "password"
+	ss.Plugin = "obfs-local"
+	ss.PluginOpts = "obfs=http;obfs-host=example.com"
+
+	zero := uint(0)
+	cases := []struct {
+		Name    string
+		Service *models.Service
+		Node    *models.Node
+		Want    *clashNode
+		Error   bool
+	}{
+		{
+			"Vmess over ws with tls",
+			&ws,
+			&node,
+			&clashNode{
+				Name:           "ws",
+				Type:           "vmess",
+				Server:         "example.com",
+				Port:           443,
+				UUID:           ws.VmessUser.UUID,
+				AlterID:        &zero,
+				Cipher:         "auto",
+				UDP:            true,
+				TLS:            true,
+				ServerName:     "example.com",
+				SkipCertVerify: true,
+				Network:        "ws",
+				WSOpts:         &wsOpts{Path: "/", Headers: map[string]string{"Host": "cdn.example.com"}},
+			},
+			false,
+		},
+		{
+			"Trojan over grpc",
+			&trojan,
+			nil,
+			&clashNode{
+				Name:     "trojan",
+				Type:     "trojan",
+				Server:   "example.com",
+				Port:     443,
+				Password: "password",
+				SNI:      "example.com",
+				Network:  "grpc",
+				GRPCOpts: &grpcOpts{ServiceName: "raydash"},
+			},
+			false,
+		},
+		{
+			"Vless with reality",
+			&vless,
+			nil,
+			&clashNode{
+				Name:        "vless",
+				Type:        "vless",
+				Server:      "example.com",
+				Port:        443,
+				UUID:        vless.VlessUser.UUID,
+				Flow:        "xtls-rprx-vision",
+				TLS:         true,
+				ServerName:  "www.example.com",
+				Fingerprint: "chrome",
+				RealityOpts: &realityOpts{PublicKey: "public", ShortID: "6ba85179e30d4fc2"},
+			},
+			false,
+		},
+		{
+			"Shadowsocks with obfs",
+			&ss,
+			&node,
+			&clashNode{
+				Name:       "ss",
+				Type:       "ss",
+				Server:     "example.com",
+				Port:       8388,
+				Cipher:     "aes-128-gcm",
+				Password:   "password",
+				UDP:        true,
+				Plugin:     "obfs",
+				PluginOpts: pluginOpts{"mode": "http", "host": "example.com"},
+			},
+			false,
+		},
+		{"Unknown protocol", &models.Service{Protocol: "unknown"}, nil, nil, true},
+		{"Unknown transport", &models.Service{VmessSetting: models.VmessSetting{StreamSettings: models.StreamSettings{TransportProtocol: "kcp"}}}, nil, nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			got, err := convert(c.Service, c.Node)
+			if c.Error {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(c.Want, got)
+		})
+	}
+}
+
+func TestConvertProvisioned(t *testing.T) {
+	assert := assertlib.New(t)
+
+	// A vmess service provisioned from a node uses AEAD, which xray nodes require
+	node := models.Node{Host: "example.com"}
+	node.Port = 443
+	user := models.User{Email: "user@example.com", UUID: "b831381d-6324-4d53-ad4f-8cda48b30811"}
+	var s models.Service
+	assert.Nil(node.ApplyTo(&s, &user))
+	assert.Nil(s.Validate())
+
+	got, err := convert(&s, &node)
+	assert.Nil(err)
+	assert.Equal("vmess", got.Type)
+	assert.Equal(uint(0), *got.AlterID)
+}
+
+func TestRender(t *testing.T) {
+	assert := assertlib.New(t)
+
+	ss := models.Service{Name: "name: with \"yaml\" chars", Host: "example.com", Port: 8388, Protocol: "shadowsocks"}
+	ss.Method = "aes-128-gcm"
+	ss.ShadowsocksUser.Password = "password"
+
+	r := &Renderer{TemplatePath: utils.AbsPath("template/clash.tmpl")}
+	var b bytes.Buffer
+	dup := ss
+	dup.Name = "Auto"
+	err := r.Render(&b, &subscription.Subscription{
+		Services: []*models.Service{&ss, &ss, &dup},
+		Profile: &models.Profile{
+			ProxyGroups: []models.ProxyGroup{{Name: "Auto", Type: "url-test", URL: "http://www.gstatic.com/generate_204", Interval: 300}},
+			Rules:       []string{"MATCH,Auto"},
+			Nameservers: []string{"https://dns.example/dns-query#a: b"},
+		},
+	})
+	assert.Nil(err)
+
+	// Output must be valid yaml holding what we put in
+	var cfg struct {
+		Proxies []map[string]interface{} `yaml:"proxies"`
+		Groups  []struct {
+			Name    string   `yaml:"name"`
+			Proxies []string `yaml:"proxies"`
+		} `yaml:"proxy-groups"`
+		Rules []string `yaml:"rules"`
+		DNS   struct {
+			Nameserver []string `yaml:"nameserver"`
+		} `yaml:"dns"`
+	}
+	assert.Nil(yaml.Unmarshal(b.Bytes(), &cfg))
+	assert.Equal(ss.Name, cfg.Proxies[0]["name"])
+	assert.Equal("ss", cfg.Proxies[0]["type"])
+	// Duplicated names and names of groups get a suffix
+	unique := []string{ss.Name, ss.Name + " 2", "Auto 2"}
+	assert.Equal(append([]string{"Auto"}, unique...), cfg.Groups[0].Proxies)
+	assert.Equal(unique, cfg.Groups[1].Proxies)
+	assert.Equal([]string{"MATCH,Auto"}, cfg.Rules)
+	assert.Equal([]string{"https://dns.example/dns-query#a: b"}, cfg.DNS.Nameserver)
+
+	// Template errors are returned instead of panic
+	broken := &Renderer{TemplatePath: utils.AbsPath("template/not-exists.tmpl")}
+	assert.NotNil(broken.Render(&b, &subscription.Subscription{}))
+}
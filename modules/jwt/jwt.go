@@ -43,8 +43,9 @@ func Verify(token []byte, key []byte) (*TokenPayload, error) {
 	return &plain, nil
 }
 
-// SignRefreshToken signs a refresh token of a user
-func SignRefreshToken(user *model.User) (token string, err error) {
+// SignRefreshToken signs a refresh token of a user and starts a session of it,
+// userAgent and ip tell the user where the session is from
+func SignRefreshToken(user *model.User, userAgent, ip string) (token string, err error) {
 	var key []byte
 	key, err = user.GetJwtKey()
 	if err != nil {
@@ -57,7 +58,7 @@ func SignRefreshToken(user *model.User) (token string, err error) {
 			Issuer:         "RayDash",
 			Subject:        "RefreshToken",
 			Audience:       jwt.Audience{},
-			ExpirationTime: jwt.NumericDate(now.Add(24 * time.Hour)), // Hard code 1 day refreshToken expire time for now
+			ExpirationTime: jwt.NumericDate(now.Add(model.SessionTTL)),
 			NotBefore:      jwt.NumericDate(now),
 			IssuedAt:       jwt.NumericDate(now),
 			JWTID:          uuid.New().String(),
@@ -66,16 +67,22 @@ func SignRefreshToken(user *model.User) (token string, err error) {
 		Username: user.Username,
 	}
 	var tokenb []byte
-	tokenb, err = jwt.Sign(plain, hs)
+	if tokenb, err = jwt.Sign(plain, hs); err != nil {
+		return
+	}
 	token = string(tokenb)
-	if user.Token == nil {
-		user.Token = make(map[string]time.Time)
+	session := model.Session{
+		UserID:     user.ID,
+		TokenHash:  model.HashSessionToken(token),
+		UserAgent:  userAgent,
+		IP:         ip,
+		ExpireAt:   now.Add(model.SessionTTL),
+		LastUsedAt: now,
 	}
-	user.Token[token] = now.Add(24 * time.Hour)
-	if err := orm.DB.Save(&user).Error; err != nil {
+	if err := orm.DB.Create(&session).Error; err != nil {
 		return "", fmt.Errorf("Database error: %w", err)
 	}
-	return token, err
+	return token, nil
 }
 
 // SignAccessToken signs a access token of a user
@@ -122,3 +129,32 @@ func ParseUID(token string) (uint64, error) {
 	}
 	return payload.UID, nil
 }
+
+// SignMFAToken signs a token proving the password of user has been checked,
+// it is exchanged for access and refresh tokens along with a second factor
+func SignMFAToken(user *model.User) (token string, err error) {
+	var key []byte
+	key, err = user.GetJwtKey()
+	if err != nil {
+		return
+	}
+	var hs = jwt.NewHS512(key)
+	now := time.Now()
+	plain := TokenPayload{
+		Payload: jwt.Payload{
+			Issuer:         "RayDash",
+			Subject:        "MFAChallenge",
+			Audience:       jwt.Audience{},
+			ExpirationTime: jwt.NumericDate(now.Add(5 * time.Minute)), // enough to open an authenticator app
+			NotBefore:      jwt.NumericDate(now),
+			IssuedAt:       jwt.NumericDate(now),
+			JWTID:          uuid.New().String(),
+		},
+		UID:      user.ID,
+		Username: user.Username,
+	}
+	var tokenb []byte
+	tokenb, err = jwt.Sign(plain, hs)
+	token = string(tokenb)
+	return token, err
+}
@@ -0,0 +1,225 @@
+// Package lockout throttles failed logins by username and client ip, every
+// failure beyond the free ones doubles the wait before the next attempt
+// and too many failures lock the key for a while.
+package lockout
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/setting"
+)
+
+// Attempt is the failure record of a key
+type Attempt struct {
+	Failures    int
+	LastFailure time.Time
+	LockedUntil time.Time // zero means not locked
+}
+
+// Limit is the thresholds of a kind of key
+type Limit struct {
+	Free int // failures allowed without waiting
+	Max  int // failures before lockout, not above 0 disables it
+}
+
+// Policy configures a Guard
+type Policy struct {
+	Backoff    time.Duration // wait after the first failure beyond free ones
+	MaxBackoff time.Duration
+	Lockout    time.Duration // also how long failures are remembered
+	User       Limit
+	IP         Limit // usually looser since many users may share an ip
+}
+
+// DefaultPolicy is used for settings missing from config
+var DefaultPolicy = Policy{
+	Backoff:    time.Second,
+	MaxBackoff: time.Minute,
+	Lockout:    15 * time.Minute,
+	User:       Limit{Free: 2, Max: 5},
+	IP:         Limit{Free: 10, Max: 50},
+}
+
+// Guard tracks failures in Store following Policy
+type Guard struct {
+	Store  Store
+	Policy Policy
+	mu     sync.Mutex // attempts are read and written back as a whole
+}
+
+// Default is the guard of login endpoints
+var Default *Guard
+
+func init() {
+	p := DefaultPolicy
+	if d := setting.Config.GetDuration("login.backoff"); d > 0 {
+		p.Backoff = d
+	}
+	if d := setting.Config.GetDuration("login.maxbackoff"); d > 0 {
+		p.MaxBackoff = d
+	}
+	if d := setting.Config.GetDuration("login.lockout"); d > 0 {
+		p.Lockout = d
+	}
+	if setting.Config.IsSet("login.user.free") {
+		p.User.Free = setting.Config.GetInt("login.user.free")
+	}
+	if setting.Config.IsSet("login.user.max") {
+		p.User.Max = setting.Config.GetInt("login.user.max")
+	}
+	if setting.Config.IsSet("login.ip.free") {
+		p.IP.Free = setting.Config.GetInt("login.ip.free")
+	}
+	if setting.Config.IsSet("login.ip.max") {
+		p.IP.Max = setting.Config.GetInt("login.ip.max")
+	}
+
+	var store Store
+	switch setting.Config.GetString("login.store") {
+	case "database":
+		store = &DBStore{}
+	case "", "memory":
+		store = NewMemoryStore()
+	default:
+		log.Log.WithField("store", setting.Config.GetString("login.store")).Fatal("Unknown Login Attempt Store")
+	}
+	Default = NewGuard(store, p)
+}
+
+// NewGuard returns a Guard instance
+func NewGuard(store Store, policy Policy) *Guard {
+	return &Guard{Store: store, Policy: policy}
+}
+
+func userKey(username string) string {
+	return "user:" + strings.ToLower(username)
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Reserve counts an attempt of username from ip as failed before it is
+// verified, so concurrent attempts can not get past the limits, unless
+// they have to wait first. It returns how long to wait, in which case
+// nothing is counted, and whether the username is locked by this attempt.
+// Attempts which turn out valid are given back by Release.
+func (g *Guard) Reserve(username, ip string, now time.Time) (wait time.Duration, locked bool, err error) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	keys := []struct {
+		Key   string
+		Limit Limit
+	}{{userKey(username), g.Policy.User}, {ipKey(ip), g.Policy.IP}}
+	attempts := make([]Attempt, len(keys))
+	for i, k := range keys {
+		a, err := g.Store.Get(k.Key)
+		if err != nil {
+			return 0, false, err
+		}
+		attempts[i] = g.current(a, now)
+		if w := g.wait(attempts[i], k.Limit, now); w > wait {
+			wait = w
+		}
+	}
+	if wait > 0 {
+		return wait, false, nil
+	}
+
+	for i, k := range keys {
+		a := attempts[i]
+		a.Failures++
+		a.LastFailure = now
+		l := k.Limit.Max > 0 && a.Failures >= k.Limit.Max && a.LockedUntil.IsZero()
+		if l {
+			a.LockedUntil = now.Add(g.Policy.Lockout)
+		}
+		if err := g.Store.Put(k.Key, a); err != nil {
+			return 0, false, err
+		}
+		if i == 0 {
+			locked = l
+		}
+	}
+	return 0, locked, nil
+}
+
+// Release gives back an attempt reserved by Reserve which turned out valid,
+// lifting the lockout it may have caused
+func (g *Guard) Release(username, ip string, now time.Time) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	if err := g.release(userKey(username), g.Policy.User, now); err != nil {
+		return err
+	}
+	return g.release(ipKey(ip), g.Policy.IP, now)
+}
+
+// Succeed forgets failures of username, failures of the ip are kept
+// so one valid account does not lift throttling of the ip
+func (g *Guard) Succeed(username string) error {
+	return g.Store.Delete(userKey(username))
+}
+
+// Unlock lifts lockout and backoff of username
+func (g *Guard) Unlock(username string) error {
+	return g.Store.Delete(userKey(username))
+}
+
+// Prune drops attempts which no longer count
+func (g *Guard) Prune(now time.Time) error {
+	return g.Store.Prune(now.Add(-g.Policy.Lockout))
+}
+
+func (g *Guard) release(key string, limit Limit, now time.Time) error {
+	a, err := g.Store.Get(key)
+	if err != nil {
+		return err
+	}
+	a = g.current(a, now)
+	if a.Failures == 0 {
+		return nil
+	}
+	a.Failures--
+	if limit.Max <= 0 || a.Failures < limit.Max {
+		a.LockedUntil = time.Time{}
+	}
+	return g.Store.Put(key, a)
+}
+
+// current forgets a lockout which has ended and failures too old to count
+func (g *Guard) current(a Attempt, now time.Time) Attempt {
+	if !a.LockedUntil.IsZero() && !now.Before(a.LockedUntil) {
+		return Attempt{}
+	}
+	if a.LockedUntil.IsZero() && now.Sub(a.LastFailure) >= g.Policy.Lockout {
+		return Attempt{}
+	}
+	return a
+}
+
+// wait of a current attempt
+func (g *Guard) wait(a Attempt, limit Limit, now time.Time) time.Duration {
+	if !a.LockedUntil.IsZero() {
+		return a.LockedUntil.Sub(now)
+	}
+	if a.Failures <= limit.Free {
+		return 0
+	}
+	backoff := g.Policy.Backoff
+	for i := limit.Free + 1; i < a.Failures && backoff < g.Policy.MaxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > g.Policy.MaxBackoff {
+		backoff = g.Policy.MaxBackoff
+	}
+	if w := a.LastFailure.Add(backoff).Sub(now); w > 0 {
+		return w
+	}
+	return 0
+}
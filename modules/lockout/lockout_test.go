@@ -0,0 +1,112 @@
+package lockout_test
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/coolray-dev/raydash/modules/lockout"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestGuard(t *testing.T) {
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	stores := map[string]lockout.Store{
+		"Memory":   lockout.NewMemoryStore(),
+		"Database": &lockout.DBStore{},
+	}
+	for name, store := range stores {
+		t.Run(name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			g := lockout.NewGuard(store, lockout.Policy{
+				Backoff:    time.Second,
+				MaxBackoff: 3 * time.Second,
+				Lockout:    time.Hour,
+				User:       lockout.Limit{Free: 1, Max: 5},
+				IP:         lockout.Limit{Free: 100},
+			})
+			now := time.Now().Truncate(time.Second)
+			reserve := func(ip string) (time.Duration, bool) {
+				wait, locked, err := g.Reserve("alice", ip, now)
+				assert.Nil(err)
+				return wait, locked
+			}
+			wait := func(ip string) time.Duration {
+				w, _ := reserve(ip)
+				return w
+			}
+
+			assert.Zero(wait("10.0.0.1"))
+			assert.Zero(wait("10.0.0.1"), "first failure is free")
+
+			// Backoff doubles up to the max, usernames are case insensitive
+			assert.Equal(time.Second, wait("10.0.0.2"))
+			now = now.Add(time.Second)
+			assert.Zero(wait("10.0.0.2"))
+			assert.Equal(2*time.Second, wait("10.0.0.2"))
+			now = now.Add(2 * time.Second)
+			assert.Zero(wait("10.0.0.2"))
+			assert.Equal(3*time.Second, wait("10.0.0.2"))
+			now = now.Add(3 * time.Second)
+
+			w, locked := reserve("10.0.0.2")
+			assert.Zero(w)
+			assert.True(locked, "fifth attempt locks")
+			assert.Equal(time.Hour, wait("10.0.0.2"))
+
+			// A valid fifth attempt lifts the lockout it caused
+			assert.Nil(g.Release("Alice", "10.0.0.2", now))
+			assert.Equal(3*time.Second, wait("10.0.0.2"))
+
+			// Lockout ends by itself
+			now = now.Add(time.Hour)
+			assert.Zero(wait("10.0.0.2"))
+			assert.Zero(wait("10.0.0.2"))
+			assert.Equal(time.Second, wait("10.0.0.2"))
+
+			assert.Nil(g.Unlock("ALICE"))
+			assert.Zero(wait("10.0.0.2"))
+
+			// Old attempts are pruned
+			reserve("10.0.0.1")
+			assert.Nil(g.Prune(now.Add(2 * time.Hour)))
+			a, err := store.Get("ip:10.0.0.1")
+			assert.Nil(err)
+			assert.Zero(a.Failures)
+
+			testBurst(t, store)
+		})
+	}
+}
+
+// testBurst checks that concurrent attempts all see each other
+func testBurst(t *testing.T, store lockout.Store) {
+	assert := assertlib.New(t)
+	g := lockout.NewGuard(store, lockout.Policy{
+		Lockout: time.Hour,
+		User:    lockout.Limit{Free: 100, Max: 5},
+		IP:      lockout.Limit{Free: 100},
+	})
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	passed := 0
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			wait, _, err := g.Reserve("bob", "10.0.0.3", time.Now())
+			assert.Nil(err)
+			if wait == 0 {
+				mu.Lock()
+				passed++
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Equal(5, passed)
+}
@@ -0,0 +1,106 @@
+package lockout
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+)
+
+// Store keeps attempts by key, a missing key reads as zero Attempt
+type Store interface {
+	Get(key string) (Attempt, error)
+	Put(key string, a Attempt) error
+	Delete(key string) error
+	Prune(before time.Time) error // drops attempts which failed and unlocked before
+}
+
+// MemoryStore keeps attempts in process, they are lost on restart
+type MemoryStore struct {
+	mu       sync.Mutex
+	attempts map[string]Attempt
+}
+
+// NewMemoryStore returns a MemoryStore instance
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{attempts: make(map[string]Attempt)}
+}
+
+// Get implements Store
+func (s *MemoryStore) Get(key string) (Attempt, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.attempts[key], nil
+}
+
+// Put implements Store
+func (s *MemoryStore) Put(key string, a Attempt) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attempts[key] = a
+	return nil
+}
+
+// Delete implements Store
+func (s *MemoryStore) Delete(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.attempts, key)
+	return nil
+}
+
+// Prune implements Store
+func (s *MemoryStore) Prune(before time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for k, a := range s.attempts {
+		if a.LastFailure.Before(before) && a.LockedUntil.Before(before) {
+			delete(s.attempts, k)
+		}
+	}
+	return nil
+}
+
+// DBStore keeps attempts in login_attempts table so they survive restarts.
+// Guard only reserves attempts atomically within one process, instances
+// sharing the database could each let a burst through.
+type DBStore struct{}
+
+// Get implements Store
+func (s *DBStore) Get(key string) (Attempt, error) {
+	var record models.LoginAttempt
+	if err := orm.DB.Where("name = ?", key).First(&record).Error; errors.Is(err, gorm.ErrRecordNotFound) {
+		return Attempt{}, nil
+	} else if err != nil {
+		return Attempt{}, err
+	}
+	return Attempt{
+		Failures:    record.Failures,
+		LastFailure: record.LastFailure,
+		LockedUntil: record.LockedUntil,
+	}, nil
+}
+
+// Put implements Store
+func (s *DBStore) Put(key string, a Attempt) error {
+	return orm.DB.Save(&models.LoginAttempt{
+		Name:        key,
+		Failures:    a.Failures,
+		LastFailure: a.LastFailure,
+		LockedUntil: a.LockedUntil,
+	}).Error
+}
+
+// Delete implements Store
+func (s *DBStore) Delete(key string) error {
+	return orm.DB.Where("name = ?", key).Delete(&models.LoginAttempt{}).Error
+}
+
+// Prune implements Store
+func (s *DBStore) Prune(before time.Time) error {
+	return orm.DB.Where("last_failure < ? AND locked_until < ?", before, before).Delete(&models.LoginAttempt{}).Error
+}
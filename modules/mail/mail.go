@@ -4,6 +4,7 @@ import (
 	"crypto/tls"
 	"errors"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/coolray-dev/raydash/models"
@@ -14,6 +15,62 @@ import (
 // MailChan is a public channel that recieve mail from other modules
 var MailChan chan *models.Mail
 
+// failures counts mails failed to send
+var failures uint64
+
+// stopping is closed when the worker stops so Queue gives up waiting
+var stopping = make(chan struct{})
+
+// closing keeps Queue from sending to MailChan once it is closed
+var closing struct {
+	sync.RWMutex
+	closed bool
+}
+
+// Failures returns number of mails failed to send since start
+func Failures() uint64 {
+	return atomic.LoadUint64(&failures)
+}
+
+// Send queues a mail without blocking, the mail is dropped when the queue
+// is full or mail is not set up. It returns whether the mail is queued.
+func Send(m *models.Mail) bool {
+	select {
+	case MailChan <- m:
+		return true
+	default:
+		log.Log.WithField("to", m.To).WithField("subject", m.Subject).Warn("Mail Queue Unavailable, Dropping Mail")
+		return false
+	}
+}
+
+// Queue queues mails in the background, waiting for room in the queue
+// instead of dropping them. Mails not queued when the worker stops are dropped.
+func Queue(mails ...*models.Mail) {
+	go func() {
+		for i, m := range mails {
+			if !enqueue(m) {
+				log.Log.WithField("count", len(mails)-i).Warn("Mail Queue Unavailable, Dropping Mails")
+				return
+			}
+		}
+	}()
+}
+
+func enqueue(m *models.Mail) bool {
+	closing.RLock()
+	defer closing.RUnlock()
+	if closing.closed || MailChan == nil {
+		return false
+	}
+	select {
+	case MailChan <- m:
+		return true
+	case <-stopping:
+		return false
+	}
+}
+
 // Worker is a mail handler
 type Worker struct {
 	host          string
@@ -46,7 +103,11 @@ func (w *Worker) Start() {
 
 // Stop stops a worker instance
 func (w *Worker) Stop() {
+	close(stopping)
+	closing.Lock()
+	closing.closed = true
 	close(w.MailChannel)
+	closing.Unlock()
 	w.WaitGroup.Done()
 	return
 }
@@ -72,6 +133,7 @@ func (w *Worker) startWorker() {
 	for mail := range w.MailChannel {
 		time.Sleep(5 * time.Second)
 		if err := w.send(mail); err != nil {
+			atomic.AddUint64(&failures, 1)
 			log.Log.WithError(err).Error("Error Sending Email")
 		}
 	}
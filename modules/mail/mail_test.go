@@ -0,0 +1,27 @@
+package mail
+
+import (
+	"testing"
+	"time"
+
+	"github.com/coolray-dev/raydash/models"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestQueue(t *testing.T) {
+	assert := assertlib.New(t)
+
+	MailChan = make(chan *models.Mail, 1)
+	defer func() { MailChan = nil }()
+
+	// More mails than the queue holds are all delivered in order
+	Queue(&models.Mail{To: "a@example.com"}, &models.Mail{To: "b@example.com"}, &models.Mail{To: "c@example.com"})
+	for _, to := range []string{"a@example.com", "b@example.com", "c@example.com"} {
+		select {
+		case m := <-MailChan:
+			assert.Equal(to, m.To)
+		case <-time.After(time.Second):
+			t.Fatalf("mail to %s not queued", to)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+// Package maintenance tells users having services on a node when the node
+// enters or leaves maintenance.
+package maintenance
+
+import (
+	"fmt"
+	"time"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/mail"
+	"github.com/coolray-dev/raydash/modules/setting"
+)
+
+// Notify posts an announcement and mails users having services on the node,
+// failing to notify is only logged
+func Notify(n *models.Node, entering bool) {
+	ann := models.Announcement{
+		Level:   "info",
+		Title:   fmt.Sprintf("Node %s is back in service", n.Name),
+		Content: fmt.Sprintf("Maintenance of node %s has finished.", n.Name),
+	}
+	if entering {
+		ann.Level = "warning"
+		ann.Title = fmt.Sprintf("Node %s is under maintenance", n.Name)
+		ann.Content = fmt.Sprintf("Node %s is under maintenance", n.Name)
+		if n.MaintenanceStart != nil {
+			ann.Content += " from " + n.MaintenanceStart.Format(time.RFC1123)
+		}
+		if n.MaintenanceEnd != nil {
+			ann.Content += " until " + n.MaintenanceEnd.Format(time.RFC1123)
+		}
+		ann.Content += "."
+		if n.MaintenanceMessage != "" {
+			ann.Content += "\n\n" + n.MaintenanceMessage
+		}
+	}
+	if err := orm.DB.Create(&ann).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+	}
+
+	var emails []string
+	if err := orm.DB.Model(&models.User{}).
+		Where("id IN (?)", orm.DB.Model(&models.Service{}).Select("user_id").Where("node_id = ?", n.ID)).
+		Pluck("email", &emails).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		return
+	}
+
+	// Queue waits for room so mails are not dropped on nodes with many users
+	mails := make([]*models.Mail, len(emails))
+	for i, email := range emails {
+		mails[i] = &models.Mail{
+			From:        setting.Config.GetString("mail.from"),
+			To:          email,
+			Subject:     ann.Title,
+			ContentType: "text/plain",
+			Content:     ann.Content,
+		}
+	}
+	mail.Queue(mails...)
+}
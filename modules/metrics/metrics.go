@@ -0,0 +1,153 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/mail"
+)
+
+const namespace = "raydash"
+
+// Registry holds all metrics of RayDash
+var Registry = prometheus.NewRegistry()
+
+var (
+	requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Number of HTTP requests by route and status.",
+	}, []string{"method", "route", "status"})
+
+	latency = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "Latency of HTTP requests by route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	denials = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "casbin",
+		Name:      "denials_total",
+		Help:      "Number of requests denied by casbin by role.",
+	}, []string{"role"})
+
+	mailQueue = prometheus.NewGaugeFunc(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Subsystem: "mail",
+		Name:      "queue_depth",
+		Help:      "Number of mails waiting to be sent.",
+	}, func() float64 {
+		return float64(len(mail.MailChan))
+	})
+
+	mailFailures = prometheus.NewCounterFunc(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "mail",
+		Name:      "send_failures_total",
+		Help:      "Number of mails failed to send.",
+	}, func() float64 {
+		return float64(mail.Failures())
+	})
+)
+
+func init() {
+	Registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+		requests,
+		latency,
+		denials,
+		mailQueue,
+		mailFailures,
+		&dbCollector{},
+	)
+}
+
+// ObserveRequest records a finished HTTP request
+func ObserveRequest(method, route string, status int, d time.Duration) {
+	s := strconv.Itoa(status)
+	requests.WithLabelValues(method, route, s).Inc()
+	latency.WithLabelValues(method, route, s).Observe(d.Seconds())
+}
+
+// Deny records a request denied by casbin
+func Deny(role string) {
+	denials.WithLabelValues(role).Inc()
+}
+
+// Handler serves metrics in Prometheus text format
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{})
+}
+
+var (
+	nodeTrafficDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "node", "traffic_bytes"),
+		"Traffic used by node in current cycle.",
+		[]string{"nid", "name"}, nil)
+	nodeMaxTrafficDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "node", "max_traffic_bytes"),
+		"Traffic quota of node.",
+		[]string{"nid", "name"}, nil)
+	userTrafficDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "user", "traffic_bytes"),
+		"Traffic used by user in current cycle.",
+		[]string{"username"}, nil)
+	usersDesc = prometheus.NewDesc(
+		prometheus.BuildFQName(namespace, "", "users"),
+		"Number of users by status.",
+		[]string{"status"}, nil)
+)
+
+// dbCollector reads gauges from database on every scrape
+type dbCollector struct{}
+
+// Describe implements prometheus.Collector
+func (c *dbCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- nodeTrafficDesc
+	ch <- nodeMaxTrafficDesc
+	ch <- userTrafficDesc
+	ch <- usersDesc
+}
+
+// Collect implements prometheus.Collector
+func (c *dbCollector) Collect(ch chan<- prometheus.Metric) {
+	var nodes []models.Node
+	if err := orm.DB.Select("id", "name", "current_traffic", "max_traffic").Find(&nodes).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		return
+	}
+	for _, n := range nodes {
+		nid := strconv.FormatUint(n.ID, 10)
+		ch <- prometheus.MustNewConstMetric(nodeTrafficDesc, prometheus.GaugeValue, float64(n.CurrentTraffic), nid, n.Name)
+		ch <- prometheus.MustNewConstMetric(nodeMaxTrafficDesc, prometheus.GaugeValue, float64(n.MaxTraffic), nid, n.Name)
+	}
+
+	var users []models.User
+	if err := orm.DB.Select("username", "current_traffic").Find(&users).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		return
+	}
+	for _, u := range users {
+		ch <- prometheus.MustNewConstMetric(userTrafficDesc, prometheus.GaugeValue, float64(u.CurrentTraffic), u.Username)
+	}
+
+	var active int64
+	if err := orm.DB.Model(&models.User{}).Scopes(models.ActiveUsers).Count(&active).Error; err != nil {
+		log.Log.WithError(err).Error("Database Error")
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(active), models.UserActive)
+	ch <- prometheus.MustNewConstMetric(usersDesc, prometheus.GaugeValue, float64(int64(len(users))-active), models.UserSuspended)
+}
@@ -0,0 +1,74 @@
+package metrics_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/coolray-dev/raydash/modules/setting"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestMetrics(t *testing.T) {
+	cases := []struct {
+		Name   string
+		Token  string // configured token
+		Auth   string // token in request
+		Remote string
+		Proxy  string // trusted proxy
+		XFF    string // X-Forwarded-For in request
+		Status int
+	}{
+		{"Loopback", "", "", "127.0.0.1:4321", "", "", http.StatusOK},
+		{"Remote", "", "", "192.0.2.1:4321", "", "", http.StatusForbidden},
+		{"Remote with token", "secret", "secret", "192.0.2.1:4321", "", "", http.StatusOK},
+		{"Remote with wrong token", "secret", "guess", "192.0.2.1:4321", "", "", http.StatusForbidden},
+		{"Remote spoofing loopback", "", "", "192.0.2.1:4321", "", "127.0.0.1", http.StatusForbidden},
+		{"Loopback through proxy", "", "", "198.51.100.1:4321", "198.51.100.1", "127.0.0.1", http.StatusOK},
+		{"Remote through proxy", "", "", "198.51.100.1:4321", "198.51.100.1", "192.0.2.1", http.StatusForbidden},
+		{"Remote spoofing through proxy", "", "", "198.51.100.1:4321", "198.51.100.1", "127.0.0.1, 192.0.2.1", http.StatusForbidden},
+	}
+
+	defer setting.Config.Set("metrics.token", "")
+	defer setting.Config.Set("app.trustedProxies", []string{})
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+
+			setting.Config.Set("metrics.token", c.Token)
+			proxies := []string{}
+			if c.Proxy != "" {
+				proxies = append(proxies, c.Proxy)
+			}
+			setting.Config.Set("app.trustedProxies", proxies)
+			router := testutils.GetRouter()
+
+			// Make sure there is a request to count
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/v1/nodes", nil)
+			router.ServeHTTP(w, req)
+
+			w = httptest.NewRecorder()
+			req, _ = http.NewRequest("GET", "/metrics", nil)
+			req.RemoteAddr = c.Remote
+			if c.XFF != "" {
+				req.Header.Add("X-Forwarded-For", c.XFF)
+				req.Header.Add("X-Real-Ip", c.XFF)
+			}
+			if c.Auth != "" {
+				req.Header.Add("Authorization", "Bearer "+c.Auth)
+			}
+			router.ServeHTTP(w, req)
+
+			assert.Equal(c.Status, w.Code)
+			if w.Code != http.StatusOK {
+				return
+			}
+			assert.Contains(w.Body.String(), `raydash_http_requests_total{method="GET",route="/v1/nodes",status="403"}`)
+			assert.Contains(w.Body.String(), `raydash_casbin_denials_total{role="anonymous"}`)
+			assert.Contains(w.Body.String(), "raydash_mail_queue_depth")
+			assert.Contains(w.Body.String(), `raydash_users{status="active"}`)
+		})
+	}
+}
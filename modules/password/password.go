@@ -0,0 +1,105 @@
+// Package password hashes passwords with argon2id in the PHC string format,
+// such as $argon2id$v=19$m=65536,t=1,p=4$<salt>$<key>, so every hash carries
+// its own salt and parameters. Unsalted sha256 hashes made by utils.Hash are
+// still accepted so they could be upgraded on the next login.
+package password
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"strings"
+
+	"golang.org/x/crypto/argon2"
+
+	"github.com/coolray-dev/raydash/modules/utils"
+)
+
+// Params are argon2id parameters
+type Params struct {
+	Memory      uint32 // KiB
+	Iterations  uint32
+	Parallelism uint8
+	SaltLength  uint32
+	KeyLength   uint32
+}
+
+// DefaultParams follow the second recommended option of RFC 9106
+var DefaultParams = Params{
+	Memory:      64 * 1024,
+	Iterations:  1,
+	Parallelism: 4,
+	SaltLength:  16,
+	KeyLength:   32,
+}
+
+// ErrInvalidHash means the stored hash could not be parsed
+var ErrInvalidHash = errors.New("Invalid password hash")
+
+const prefix = "$argon2id$"
+
+// Hash hashes plain with DefaultParams and a random salt
+func Hash(plain string) (string, error) {
+	p := DefaultParams
+	salt := make([]byte, p.SaltLength)
+	if _, err := rand.Read(salt); err != nil {
+		return "", fmt.Errorf("Error generating salt: %w", err)
+	}
+	key := argon2.IDKey([]byte(plain), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	return fmt.Sprintf("%sv=%d$m=%d,t=%d,p=%d$%s$%s", prefix, argon2.Version,
+		p.Memory, p.Iterations, p.Parallelism,
+		base64.RawStdEncoding.EncodeToString(salt),
+		base64.RawStdEncoding.EncodeToString(key)), nil
+}
+
+// Verify tells whether plain matches hash. Rehash is true when hash matches
+// but is legacy or made with other parameters, caller should then store a new
+// hash of plain.
+func Verify(plain, hash string) (ok bool, rehash bool, err error) {
+	if !strings.HasPrefix(hash, prefix) {
+		// Legacy unsalted sha256
+		ok = subtle.ConstantTimeCompare([]byte(utils.Hash(plain)), []byte(hash)) == 1
+		return ok, ok, nil
+	}
+
+	p, salt, key, err := decode(hash)
+	if err != nil {
+		return false, false, err
+	}
+	other := argon2.IDKey([]byte(plain), salt, p.Iterations, p.Memory, p.Parallelism, p.KeyLength)
+	if subtle.ConstantTimeCompare(key, other) != 1 {
+		return false, false, nil
+	}
+	return true, p != DefaultParams, nil
+}
+
+// decode parses a hash made by Hash
+func decode(hash string) (p Params, salt, key []byte, err error) {
+	// "", "argon2id", "v=19", "m=...,t=...,p=...", salt, key
+	parts := strings.Split(hash, "$")
+	if len(parts) != 6 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	var version int
+	if _, err := fmt.Sscanf(parts[2], "v=%d", &version); err != nil || version != argon2.Version {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if _, err := fmt.Sscanf(parts[3], "m=%d,t=%d,p=%d", &p.Memory, &p.Iterations, &p.Parallelism); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	// argon2.IDKey panics on these instead of returning an error
+	if p.Iterations == 0 || p.Parallelism == 0 || p.Memory < 8*uint32(p.Parallelism) {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if salt, err = base64.RawStdEncoding.DecodeString(parts[4]); err != nil {
+		return p, nil, nil, ErrInvalidHash
+	}
+	if key, err = base64.RawStdEncoding.DecodeString(parts[5]); err != nil || len(key) == 0 {
+		return p, nil, nil, ErrInvalidHash
+	}
+	p.SaltLength = uint32(len(salt))
+	p.KeyLength = uint32(len(key))
+	return p, salt, key, nil
+}
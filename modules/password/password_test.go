@@ -0,0 +1,58 @@
+package password
+
+import (
+	"testing"
+
+	"github.com/coolray-dev/raydash/modules/utils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestPassword(t *testing.T) {
+	assert := assertlib.New(t)
+
+	hash, err := Hash("correct horse")
+	assert.Nil(err)
+	other, err := Hash("correct horse")
+	assert.Nil(err)
+	assert.NotEqual(hash, other, "salt should differ")
+
+	cases := []struct {
+		Name   string
+		Plain  string
+		Hash   string
+		OK     bool
+		Rehash bool
+		Error  bool
+	}{
+		{"Correct", "correct horse", hash, true, false, false},
+		{"Wrong", "battery staple", hash, false, false, false},
+		{"Legacy", "correct horse", utils.Hash("correct horse"), true, true, false},
+		{"Wrong legacy", "battery staple", utils.Hash("correct horse"), false, false, false},
+		{"Other parameters", "correct horse", mustHash(t, Params{Memory: 1024, Iterations: 1, Parallelism: 1, SaltLength: 8, KeyLength: 16}), true, true, false},
+		{"Broken", "correct horse", "$argon2id$v=19$m=65536$broken", false, false, true},
+		{"No iterations", "correct horse", "$argon2id$v=19$m=65536,t=0,p=4$c2FsdHNhbHQ$a2V5a2V5", false, false, true},
+		{"No parallelism", "correct horse", "$argon2id$v=19$m=65536,t=1,p=0$c2FsdHNhbHQ$a2V5a2V5", false, false, true},
+		{"Too little memory", "correct horse", "$argon2id$v=19$m=31,t=1,p=4$c2FsdHNhbHQ$a2V5a2V5", false, false, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			ok, rehash, err := Verify(c.Plain, c.Hash)
+			assert.Equal(c.OK, ok)
+			assert.Equal(c.Rehash, rehash)
+			assert.Equal(c.Error, err != nil)
+		})
+	}
+}
+
+func mustHash(t *testing.T, p Params) string {
+	saved := DefaultParams
+	DefaultParams = p
+	defer func() { DefaultParams = saved }()
+	hash, err := Hash("correct horse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return hash
+}
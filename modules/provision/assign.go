@@ -0,0 +1,136 @@
+package provision
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/casbin"
+)
+
+// ErrNotFound is returned when a group or node to assign does not exist
+var ErrNotFound = errors.New("not found")
+
+// Assignment is a node assigned to a group
+type Assignment struct {
+	Group *models.Group
+	Node  *models.Node
+}
+
+func (a Assignment) key() [2]uint64 {
+	return [2]uint64{a.Group.ID, a.Node.ID}
+}
+
+// GroupAssignments pairs group with each of nodes
+func GroupAssignments(g *models.Group, nodes []*models.Node) []Assignment {
+	pairs := make([]Assignment, len(nodes))
+	for i, n := range nodes {
+		pairs[i] = Assignment{Group: g, Node: n}
+	}
+	return pairs
+}
+
+// NodeAssignments pairs node with each of groups
+func NodeAssignments(n *models.Node, groups []*models.Group) []Assignment {
+	pairs := make([]Assignment, len(groups))
+	for i, g := range groups {
+		pairs[i] = Assignment{Group: g, Node: n}
+	}
+	return pairs
+}
+
+// Diff returns wanted assignments not in current and, if replace,
+// current assignments not in wanted
+func Diff(current, wanted []Assignment, replace bool) (add, remove []Assignment) {
+	has := make(map[[2]uint64]bool)
+	for _, a := range current {
+		has[a.key()] = true
+	}
+	want := make(map[[2]uint64]bool)
+	for _, a := range wanted {
+		want[a.key()] = true
+		if !has[a.key()] {
+			add = append(add, a)
+		}
+	}
+	if replace {
+		for _, a := range current {
+			if !want[a.key()] {
+				remove = append(remove, a)
+			}
+		}
+	}
+	return add, remove
+}
+
+// SetAssignments removes and adds assignments in one transaction with services
+// of members provisioned accordingly, casbin policies follow once committed
+func SetAssignments(tx *gorm.DB, add, remove []Assignment) error {
+	err := tx.Transaction(func(tx *gorm.DB) error {
+		for _, a := range remove {
+			if err := Unassign(tx, a.Group, a.Node); err != nil {
+				return err
+			}
+		}
+		for _, a := range add {
+			if err := Assign(tx, a.Group, a.Node); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	for _, a := range remove {
+		casbin.RemoveGroupNodePolicy(a.Group, a.Node)
+	}
+	for _, a := range add {
+		casbin.AddGroupNodePolicy(a.Group, a.Node)
+	}
+	return nil
+}
+
+// FindNodes loads nodes of ids, a missing one is ErrNotFound
+func FindNodes(tx *gorm.DB, ids []uint64) ([]*models.Node, error) {
+	var nodes []*models.Node
+	if len(ids) == 0 {
+		return nodes, nil
+	}
+	if err := tx.Where("id IN ?", ids).Find(&nodes).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[uint64]bool)
+	for _, n := range nodes {
+		found[n.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("Node %d %w", id, ErrNotFound)
+		}
+	}
+	return nodes, nil
+}
+
+// FindGroups loads groups of ids, a missing one is ErrNotFound
+func FindGroups(tx *gorm.DB, ids []uint64) ([]*models.Group, error) {
+	var groups []*models.Group
+	if len(ids) == 0 {
+		return groups, nil
+	}
+	if err := tx.Where("id IN ?", ids).Find(&groups).Error; err != nil {
+		return nil, err
+	}
+	found := make(map[uint64]bool)
+	for _, g := range groups {
+		found[g.ID] = true
+	}
+	for _, id := range ids {
+		if !found[id] {
+			return nil, fmt.Errorf("Group %d %w", id, ErrNotFound)
+		}
+	}
+	return groups, nil
+}
@@ -0,0 +1,132 @@
+// Package provision keeps services in line with group membership, a user
+// has one service on every node reachable through the groups of the user.
+package provision
+
+import (
+	"gorm.io/gorm"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+)
+
+// AppendUser creates services for user on nodes of group the user has none on,
+// it should be called after the user joined the group
+func AppendUser(tx *gorm.DB, g *models.Group, u *models.User) error {
+	var nodes []*models.Node
+	if err := tx.Model(g).Association("Nodes").Find(&nodes); err != nil {
+		return err
+	}
+	for _, n := range nodes {
+		if err := ensureService(tx, n, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveUser deletes services of user on nodes of group the user can no longer
+// reach through other groups, it should be called after the user left the group
+func RemoveUser(tx *gorm.DB, g *models.Group, u *models.User) error {
+	var nids []uint64
+	if err := tx.Table("groups_nodes").Where("group_id = ?", g.ID).Pluck("node_id", &nids).Error; err != nil {
+		return err
+	}
+	return removeUnreachable(tx, u.ID, nids)
+}
+
+// AppendNode backfills services on node for members of group,
+// it should be called after the node joined the group
+func AppendNode(tx *gorm.DB, g *models.Group, n *models.Node) error {
+	var users []*models.User
+	if err := tx.Model(g).Association("Users").Find(&users); err != nil {
+		return err
+	}
+	for _, u := range users {
+		if err := ensureService(tx, n, u); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RemoveNode deletes services on node of members of group who can no longer
+// reach the node through other groups, it should be called after the node left the group
+func RemoveNode(tx *gorm.DB, g *models.Group, n *models.Node) error {
+	var uids []uint64
+	if err := tx.Table("groups_users").Where("group_id = ?", g.ID).Pluck("user_id", &uids).Error; err != nil {
+		return err
+	}
+	for _, uid := range uids {
+		if err := removeUnreachable(tx, uid, []uint64{n.ID}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Assign puts node into group and backfills services of members
+func Assign(tx *gorm.DB, g *models.Group, n *models.Node) error {
+	if err := tx.Model(g).Association("Nodes").Append(n); err != nil {
+		return err
+	}
+	return AppendNode(tx, g, n)
+}
+
+// Unassign takes node out of group and deletes services of members
+// who can no longer reach the node
+func Unassign(tx *gorm.DB, g *models.Group, n *models.Node) error {
+	if err := tx.Model(g).Association("Nodes").Delete(n); err != nil {
+		return err
+	}
+	return RemoveNode(tx, g, n)
+}
+
+// ensureService creates a service of user on node with node defaults the way
+// services.Store does for single port nodes, multi port nodes give it a free port
+func ensureService(tx *gorm.DB, n *models.Node, u *models.User) error {
+	var count int64
+	if err := tx.Model(&models.Service{}).Where("node_id = ? AND user_id = ?", n.ID, u.ID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return nil
+	}
+
+	service := models.Service{
+		Name:   n.Name,
+		NodeID: n.ID,
+		UserID: u.ID,
+	}
+	if err := n.ApplyTo(&service, u); err != nil {
+		return err
+	}
+	if n.HasMultiPort {
+		service.Port = 0
+		if err := models.AssignPort(tx, n, &service); err != nil {
+			return err
+		}
+	}
+	if err := tx.Create(&service).Error; err != nil {
+		return err
+	}
+	log.Log.WithField("username", u.Username).WithField("nodeID", n.ID).Info("Service Provisioned")
+	return nil
+}
+
+// removeUnreachable deletes services of user on nodes in nids which are not
+// in any group of the user
+func removeUnreachable(tx *gorm.DB, uid uint64, nids []uint64) error {
+	if len(nids) == 0 {
+		return nil
+	}
+	reachable := tx.Table("groups_nodes").Select("node_id").
+		Where("group_id IN (?)", tx.Table("groups_users").Select("group_id").Where("user_id = ?", uid))
+	result := tx.Where("user_id = ? AND node_id IN ? AND node_id NOT IN (?)", uid, nids, reachable).Delete(&models.Service{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected > 0 {
+		log.Log.WithField("userID", uid).WithField("services", result.RowsAffected).Info("Services Deprovisioned")
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package provision_test
+
+import (
+	"testing"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/provision"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestProvision(t *testing.T) {
+	assert := assertlib.New(t)
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	shared := models.Node{Name: gofakeit.Word(), Host: gofakeit.DomainName()}
+	shared.Port = 443
+	multi := models.Node{Name: gofakeit.Word(), HasMultiPort: true, Ports: "20000-20009"}
+	extra := models.Node{Name: gofakeit.Word()}
+	orm.DB.Create(&shared)
+	orm.DB.Create(&multi)
+	orm.DB.Create(&extra)
+
+	basic := models.Group{Name: gofakeit.Word(), Nodes: []*models.Node{&shared, &multi}}
+	other := models.Group{Name: gofakeit.Word(), Nodes: []*models.Node{&shared}}
+	orm.DB.Create(&basic)
+	orm.DB.Create(&other)
+
+	var user models.User
+	gofakeit.Struct(&user)
+	orm.DB.Create(&user)
+
+	services := func() map[uint64]models.Service {
+		var list []models.Service
+		orm.DB.Where("user_id = ?", user.ID).Find(&list)
+		result := make(map[uint64]models.Service)
+		for _, s := range list {
+			result[s.NodeID] = s
+		}
+		assert.Len(result, len(list), "one service per node")
+		return result
+	}
+
+	// Joining a group creates services on its nodes
+	orm.DB.Model(&basic).Association("Users").Append(&user)
+	assert.Nil(provision.AppendUser(orm.DB, &basic, &user))
+	s := services()
+	assert.Len(s, 2)
+	assert.Equal(uint(443), s[shared.ID].Port)
+	assert.Equal(user.UUID, s[shared.ID].VmessUser.UUID)
+	assert.Equal(uint(20000), s[multi.ID].Port)
+
+	// A node reached twice still has one service
+	orm.DB.Model(&other).Association("Users").Append(&user)
+	assert.Nil(provision.AppendUser(orm.DB, &other, &user))
+	assert.Len(services(), 2)
+
+	// Adding a node backfills members
+	orm.DB.Model(&other).Association("Nodes").Append(&extra)
+	assert.Nil(provision.AppendNode(orm.DB, &other, &extra))
+	assert.Contains(services(), extra.ID)
+
+	// Leaving a group keeps services other groups still give
+	orm.DB.Model(&basic).Association("Users").Delete(&user)
+	assert.Nil(provision.RemoveUser(orm.DB, &basic, &user))
+	s = services()
+	assert.Contains(s, shared.ID)
+	assert.NotContains(s, multi.ID)
+
+	// Removing a node drops services of members
+	orm.DB.Model(&other).Association("Nodes").Delete(&extra)
+	assert.Nil(provision.RemoveNode(orm.DB, &other, &extra))
+	assert.NotContains(services(), extra.ID)
+}
+
+func TestDiff(t *testing.T) {
+	assert := assertlib.New(t)
+
+	g := &models.Group{BaseModel: models.BaseModel{ID: 1}}
+	n1 := &models.Node{BaseModel: models.BaseModel{ID: 1}}
+	n2 := &models.Node{BaseModel: models.BaseModel{ID: 2}}
+	n3 := &models.Node{BaseModel: models.BaseModel{ID: 3}}
+	current := provision.GroupAssignments(g, []*models.Node{n1, n2})
+	wanted := provision.GroupAssignments(g, []*models.Node{n2, n3})
+
+	add, remove := provision.Diff(current, wanted, false)
+	assert.Equal(provision.GroupAssignments(g, []*models.Node{n3}), add)
+	assert.Empty(remove)
+
+	add, remove = provision.Diff(current, wanted, true)
+	assert.Equal(provision.GroupAssignments(g, []*models.Node{n3}), add)
+	assert.Equal(provision.GroupAssignments(g, []*models.Node{n1}), remove)
+}
@@ -0,0 +1,22 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/coolray-dev/raydash/modules/lockout"
+)
+
+// LoginAttemptPruneJob drops failed login attempts which no longer count
+type LoginAttemptPruneJob struct {
+	Guard *lockout.Guard
+}
+
+// Name implements Job
+func (j *LoginAttemptPruneJob) Name() string {
+	return "LoginAttemptPrune"
+}
+
+// Run implements Job
+func (j *LoginAttemptPruneJob) Run(now time.Time) error {
+	return j.Guard.Prune(now)
+}
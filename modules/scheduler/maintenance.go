@@ -0,0 +1,50 @@
+package scheduler
+
+import (
+	"time"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+	"github.com/coolray-dev/raydash/modules/maintenance"
+)
+
+// MaintenanceEndJob clears maintenance windows of active nodes once they end
+// and tells users the node is back in service. Nodes still in maintenance
+// state are left to the admin.
+type MaintenanceEndJob struct{}
+
+// Name implements Job
+func (j *MaintenanceEndJob) Name() string {
+	return "MaintenanceEnd"
+}
+
+// Run implements Job
+func (j *MaintenanceEndJob) Run(now time.Time) error {
+	var nodes []models.Node
+	if err := orm.DB.Where("state = ? AND maintenance_end IS NOT NULL AND maintenance_end <= ?", models.NodeActive, now).
+		Find(&nodes).Error; err != nil {
+		return err
+	}
+	for i := range nodes {
+		n := &nodes[i]
+
+		// Check again so a window the admin set meanwhile is kept
+		result := orm.DB.Model(&models.Node{}).
+			Where("id = ? AND state = ? AND maintenance_end <= ?", n.ID, models.NodeActive, now).
+			Updates(map[string]interface{}{
+				"maintenance_start":   nil,
+				"maintenance_end":     nil,
+				"maintenance_message": "",
+			})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			continue
+		}
+		log.Log.WithField("nodeID", n.ID).Info("Node Maintenance Ended")
+		maintenance.Notify(n, false)
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package scheduler
+
+import (
+	"sync"
+	"time"
+
+	"github.com/coolray-dev/raydash/modules/log"
+)
+
+// Job is a periodic task, it is run on every tick so it should check
+// by itself whether there is anything due
+type Job interface {
+	Name() string
+	Run(now time.Time) error
+}
+
+// Scheduler runs jobs one by one on a fixed interval
+type Scheduler struct {
+	interval  time.Duration
+	jobs      []Job
+	stop      chan struct{}
+	stopOnce  sync.Once
+	WaitGroup *sync.WaitGroup
+}
+
+// NewScheduler returns a Scheduler instance
+func NewScheduler(interval time.Duration, wg *sync.WaitGroup) *Scheduler {
+	var scheduler Scheduler
+	scheduler.interval = interval
+	scheduler.stop = make(chan struct{})
+	scheduler.WaitGroup = wg
+	return &scheduler
+}
+
+// Add registers a job, it should be called before Start
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+// Start starts running jobs, jobs are run once right away
+func (s *Scheduler) Start() {
+	s.WaitGroup.Add(1)
+	go s.loop()
+	log.Log.WithField("interval", s.interval).Info("Scheduler Started")
+	return
+}
+
+// Stop stops the scheduler after the running job finishes, it is safe
+// to call more than once
+func (s *Scheduler) Stop() {
+	s.stopOnce.Do(func() {
+		close(s.stop)
+	})
+	return
+}
+
+func (s *Scheduler) loop() {
+	defer s.WaitGroup.Done()
+
+	ticker := time.NewTicker(s.interval)
+	defer ticker.Stop()
+
+	s.RunOnce(time.Now())
+	for {
+		select {
+		case <-s.stop:
+			return
+		case now := <-ticker.C:
+			s.RunOnce(now)
+		}
+	}
+}
+
+// RunOnce runs every job once, a failing job does not stop the others
+func (s *Scheduler) RunOnce(now time.Time) {
+	for _, job := range s.jobs {
+		select {
+		case <-s.stop:
+			return
+		default:
+		}
+		if err := job.Run(now); err != nil {
+			log.Log.WithError(err).WithField("job", job.Name()).Error("Scheduled Job Failed")
+		}
+	}
+}
+
+// LastCycleStart returns the latest midnight on day of month not after now,
+// day beyond the end of a month means the last day of it
+func LastCycleStart(now time.Time, day int) time.Time {
+	start := cycleStart(now.Year(), now.Month(), day, now.Location())
+	if start.After(now) {
+		start = cycleStart(now.Year(), now.Month()-1, day, now.Location())
+	}
+	return start
+}
+
+func cycleStart(year int, month time.Month, day int, loc *time.Location) time.Time {
+	// Day 0 of next month is the last day of this month
+	last := time.Date(year, month+1, 0, 0, 0, 0, 0, loc).Day()
+	if day > last {
+		day = last
+	}
+	if day < 1 {
+		day = 1
+	}
+	return time.Date(year, month, day, 0, 0, 0, 0, loc)
+}
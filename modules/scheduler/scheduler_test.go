@@ -0,0 +1,182 @@
+package scheduler
+
+import (
+	"os"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/brianvoe/gofakeit/v5"
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/testutils"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	tx, teardown := testutils.Setup()
+	defer teardown(tx)
+
+	code := m.Run()
+	os.Exit(code)
+}
+
+func TestLastCycleStart(t *testing.T) {
+	date := func(y int, m time.Month, d, h int) time.Time {
+		return time.Date(y, m, d, h, 0, 0, 0, time.UTC)
+	}
+	cases := []struct {
+		Name string
+		Now  time.Time
+		Day  int
+		Want time.Time
+	}{
+		{"Same month", date(2026, 3, 15, 8), 1, date(2026, 3, 1, 0)},
+		{"Previous month", date(2026, 3, 15, 8), 20, date(2026, 2, 20, 0)},
+		{"On the day", date(2026, 3, 20, 0), 20, date(2026, 3, 20, 0)},
+		{"Short month", date(2026, 3, 15, 8), 31, date(2026, 2, 28, 0)},
+		{"Previous year", date(2026, 1, 10, 8), 15, date(2025, 12, 15, 0)},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assertlib.Equal(t, c.Want, LastCycleStart(c.Now, c.Day))
+		})
+	}
+}
+
+func TestUserResetJob(t *testing.T) {
+	assert := assertlib.New(t)
+
+	now := time.Now()
+	created := now.AddDate(0, -2, 0)
+	var user models.User
+	gofakeit.Struct(&user)
+	user.CreatedAt = created
+	user.CurrentTraffic = 200
+	user.MaxTraffic = 100
+	user.Status = models.UserSuspended
+	orm.DB.Create(&user)
+
+	job := &UserResetJob{Mode: ResetByDay, Day: 1}
+
+	// Usage of a user never seen is kept, the cycle starts counting instead
+	assert.Nil(job.Run(now))
+	orm.DB.First(&user, user.ID)
+	assert.Equal(int64(200), user.CurrentTraffic)
+	assert.Equal(models.UserSuspended, user.Status)
+	assert.Equal(LastCycleStart(now, 1).Unix(), user.LastResetAt.Unix())
+	var count int64
+	orm.DB.Model(&models.TrafficCycle{}).Where("user_id = ?", user.ID).Count(&count)
+	assert.Equal(int64(0), count)
+
+	// Reset is due once the cycle started before the current one
+	last := LastCycleStart(now.AddDate(0, -1, 0), 1)
+	orm.DB.Model(&user).UpdateColumn("last_reset_at", last)
+	assert.Nil(job.Run(now))
+
+	orm.DB.First(&user, user.ID)
+	assert.Equal(int64(0), user.CurrentTraffic)
+	assert.Equal(models.UserActive, user.Status)
+	assert.Equal(LastCycleStart(now, 1).Unix(), user.LastResetAt.Unix())
+
+	var cycle models.TrafficCycle
+	assert.Nil(orm.DB.Where("user_id = ?", user.ID).First(&cycle).Error)
+	assert.Equal(int64(200), cycle.Traffic)
+	assert.Equal(last.Unix(), cycle.Start.Unix())
+
+	// Nothing is due in the same cycle
+	orm.DB.Model(&user).UpdateColumn("current_traffic", 50)
+	assert.Nil(job.Run(now))
+	orm.DB.First(&user, user.ID)
+	assert.Equal(int64(50), user.CurrentTraffic)
+}
+
+func TestNodeResetJob(t *testing.T) {
+	assert := assertlib.New(t)
+
+	now := time.Now()
+	node := models.Node{Name: gofakeit.Username(), ResetDay: 1, CurrentTraffic: 300}
+	node.CreatedAt = now.AddDate(0, -2, 0)
+	orm.DB.Create(&node)
+
+	job := &NodeResetJob{}
+
+	// Usage of a node never seen is kept
+	assert.Nil(job.Run(now))
+	orm.DB.First(&node, node.ID)
+	assert.Equal(uint64(300), node.CurrentTraffic)
+	assert.Equal(LastCycleStart(now, 1).Unix(), node.LastResetAt.Unix())
+
+	orm.DB.Model(&node).UpdateColumn("last_reset_at", LastCycleStart(now.AddDate(0, -1, 0), 1))
+	assert.Nil(job.Run(now))
+	orm.DB.First(&node, node.ID)
+	assert.Equal(uint64(0), node.CurrentTraffic)
+}
+
+func TestMaintenanceEndJob(t *testing.T) {
+	assert := assertlib.New(t)
+
+	now := time.Now()
+	past, future := now.Add(-time.Hour), now.Add(time.Hour)
+	ended := models.Node{Name: gofakeit.Username(), State: models.NodeActive}
+	ended.MaintenanceEnd = &past
+	ended.MaintenanceMessage = "Upgrade"
+	pending := models.Node{Name: gofakeit.Username(), State: models.NodeActive}
+	pending.MaintenanceEnd = &future
+	held := models.Node{Name: gofakeit.Username(), State: models.NodeMaintenance}
+	held.MaintenanceEnd = &past
+	orm.DB.Create(&ended)
+	orm.DB.Create(&pending)
+	orm.DB.Create(&held)
+
+	job := &MaintenanceEndJob{}
+	assert.Nil(job.Run(now))
+
+	orm.DB.First(&ended, ended.ID)
+	assert.Nil(ended.MaintenanceEnd)
+	assert.Empty(ended.MaintenanceMessage)
+	orm.DB.First(&pending, pending.ID)
+	assert.NotNil(pending.MaintenanceEnd)
+	orm.DB.First(&held, held.ID)
+	assert.NotNil(held.MaintenanceEnd)
+
+	var count int64
+	orm.DB.Model(&models.Announcement{}).Where("title = ?", "Node "+ended.Name+" is back in service").Count(&count)
+	assert.Equal(int64(1), count)
+
+	// The window is gone so users are told only once
+	assert.Nil(job.Run(now))
+	orm.DB.Model(&models.Announcement{}).Where("title = ?", "Node "+ended.Name+" is back in service").Count(&count)
+	assert.Equal(int64(1), count)
+}
+
+func TestScheduler(t *testing.T) {
+	assert := assertlib.New(t)
+
+	var wg sync.WaitGroup
+	job := &countJob{}
+	s := NewScheduler(time.Hour, &wg)
+	s.Add(job)
+	s.RunOnce(time.Now())
+	assert.Equal(1, job.count)
+
+	// Stop lets the loop exit so waitgroup is released
+	s.Start()
+	s.Stop()
+	wg.Wait()
+
+	// Stopping again is harmless
+	assert.NotPanics(s.Stop)
+}
+
+type countJob struct {
+	count int
+}
+
+func (j *countJob) Name() string { return "count" }
+
+func (j *countJob) Run(now time.Time) error {
+	j.count++
+	return nil
+}
@@ -0,0 +1,21 @@
+package scheduler
+
+import (
+	"time"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+)
+
+// SessionPruneJob deletes expired sessions
+type SessionPruneJob struct{}
+
+// Name implements Job
+func (j *SessionPruneJob) Name() string {
+	return "SessionPrune"
+}
+
+// Run implements Job
+func (j *SessionPruneJob) Run(now time.Time) error {
+	return orm.DB.Where("expire_at <= ?", now).Delete(&models.Session{}).Error
+}
@@ -0,0 +1,186 @@
+package scheduler
+
+import (
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+
+	orm "github.com/coolray-dev/raydash/database"
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/log"
+)
+
+// Modes of user traffic reset
+const (
+	ResetByDay         = "day"         // every user on the same day of month
+	ResetByAnniversary = "anniversary" // every user on the day of month they registered
+)
+
+// UserResetJob archives and resets traffic of users at the start of each cycle,
+// empty Mode disables it
+type UserResetJob struct {
+	Mode string
+	Day  int
+}
+
+// Name implements Job
+func (j *UserResetJob) Name() string {
+	return "UserTrafficReset"
+}
+
+// Run implements Job
+func (j *UserResetJob) Run(now time.Time) error {
+	if j.Mode != ResetByDay && j.Mode != ResetByAnniversary {
+		return nil
+	}
+
+	var users []models.User
+	if err := orm.DB.Find(&users).Error; err != nil {
+		return err
+	}
+	for i := range users {
+		u := &users[i]
+		day := j.Day
+		if j.Mode == ResetByAnniversary {
+			day = u.CreatedAt.In(now.Location()).Day()
+		}
+		start := LastCycleStart(now, day)
+
+		// Usage of a user not seen before may all be in the current cycle,
+		// so it only starts counting cycles from here
+		if u.LastResetAt == nil {
+			if err := orm.DB.Model(&models.User{}).Where("id = ? AND last_reset_at IS NULL", u.ID).
+				UpdateColumn("last_reset_at", start).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		last := *u.LastResetAt
+		if !start.After(last) {
+			continue
+		}
+		if err := resetUser(u, last, start); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// resetUser archives traffic of the cycle between last and start then
+// takes it off the counter. Traffic reported after the user was loaded
+// stays in the new cycle.
+func resetUser(u *models.User, last, start time.Time) error {
+	err := orm.DB.Transaction(func(tx *gorm.DB) error {
+		cycle := models.TrafficCycle{
+			UserID:  u.ID,
+			Start:   last,
+			End:     start,
+			Traffic: u.CurrentTraffic,
+			Max:     u.MaxTraffic,
+		}
+		if err := tx.Create(&cycle).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(u).UpdateColumns(map[string]interface{}{
+			"current_traffic": gorm.Expr("current_traffic - ?", u.CurrentTraffic),
+			"last_reset_at":   start,
+		}).Error; err != nil {
+			return err
+		}
+
+		// Reset brings users suspended for quota back
+		return models.EnforceUsers(tx, []uint64{u.ID})
+	})
+	if err != nil {
+		return err
+	}
+	log.Log.WithFields(logrus.Fields{
+		"username": u.Username,
+		"traffic":  u.CurrentTraffic,
+		"start":    last,
+		"end":      start,
+	}).Info("User Traffic Reset")
+	return nil
+}
+
+// NodeResetJob archives and resets traffic of nodes on their own reset day
+type NodeResetJob struct{}
+
+// Name implements Job
+func (j *NodeResetJob) Name() string {
+	return "NodeTrafficReset"
+}
+
+// Run implements Job
+func (j *NodeResetJob) Run(now time.Time) error {
+	var nodes []models.Node
+	if err := orm.DB.Where("reset_day > 0").Find(&nodes).Error; err != nil {
+		return err
+	}
+	for i := range nodes {
+		n := &nodes[i]
+		start := LastCycleStart(now, int(n.ResetDay))
+
+		// Same as users, a node not seen before starts counting cycles from here
+		if n.LastResetAt == nil {
+			if err := orm.DB.Model(&models.Node{}).Where("id = ? AND last_reset_at IS NULL", n.ID).
+				UpdateColumn("last_reset_at", start).Error; err != nil {
+				return err
+			}
+			continue
+		}
+		last := *n.LastResetAt
+		if !start.After(last) {
+			continue
+		}
+		err := orm.DB.Transaction(func(tx *gorm.DB) error {
+			cycle := models.TrafficCycle{
+				NodeID:  n.ID,
+				Start:   last,
+				End:     start,
+				Traffic: int64(n.CurrentTraffic),
+				Max:     int64(n.MaxTraffic),
+			}
+			if err := tx.Create(&cycle).Error; err != nil {
+				return err
+			}
+			return tx.Model(n).UpdateColumns(map[string]interface{}{
+				"current_traffic": gorm.Expr("current_traffic - ?", n.CurrentTraffic),
+				"last_reset_at":   start,
+			}).Error
+		})
+		if err != nil {
+			return err
+		}
+		log.Log.WithFields(logrus.Fields{
+			"nodeID":  n.ID,
+			"traffic": n.CurrentTraffic,
+			"start":   last,
+			"end":     start,
+		}).Info("Node Traffic Reset")
+	}
+	return nil
+}
+
+// ExpiryJob suspends users whose plan expired since last run
+type ExpiryJob struct{}
+
+// Name implements Job
+func (j *ExpiryJob) Name() string {
+	return "UserExpiry"
+}
+
+// Run implements Job
+func (j *ExpiryJob) Run(now time.Time) error {
+	var ids []uint64
+	if err := orm.DB.Model(&models.User{}).
+		Where("status <> ? AND expire_at IS NOT NULL AND expire_at <= ?", models.UserSuspended, now).
+		Pluck("id", &ids).Error; err != nil {
+		return err
+	}
+	if len(ids) > 0 {
+		log.Log.WithField("users", ids).Info("Suspending Expired Users")
+	}
+	return models.EnforceUsers(orm.DB, ids)
+}
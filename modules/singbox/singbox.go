@@ -0,0 +1,263 @@
+package singbox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/subscription"
+)
+
+func init() {
+	subscription.Register("singbox", &Renderer{})
+}
+
+const (
+	selectorTag = "proxy"
+	urltestTag  = "auto"
+	directTag   = "direct"
+)
+
+type config struct {
+	Log       logConfig  `json:"log"`
+	Inbounds  []inbound  `json:"inbounds"`
+	Outbounds []outbound `json:"outbounds"`
+	Route     route      `json:"route"`
+}
+
+type logConfig struct {
+	Level string `json:"level"`
+}
+
+type inbound struct {
+	Type       string `json:"type"`
+	Tag        string `json:"tag"`
+	Listen     string `json:"listen"`
+	ListenPort uint   `json:"listen_port"`
+}
+
+// outbound covers the fields of all outbound types we generate,
+// unused fields are omitted
+type outbound struct {
+	Type string `json:"type"`
+	Tag  string `json:"tag"`
+
+	// selector and urltest
+	Outbounds []string `json:"outbounds,omitempty"`
+	Default   string   `json:"default,omitempty"`
+	URL       string   `json:"url,omitempty"`
+	Interval  string   `json:"interval,omitempty"`
+
+	// proxies
+	Server     string     `json:"server,omitempty"`
+	ServerPort uint       `json:"server_port,omitempty"`
+	UUID       string     `json:"uuid,omitempty"`
+	Flow       string     `json:"flow,omitempty"`
+	Security   string     `json:"security,omitempty"`
+	AlterID    uint       `json:"alter_id,omitempty"`
+	Method     string     `json:"method,omitempty"`
+	Password   string     `json:"password,omitempty"`
+	Plugin     string     `json:"plugin,omitempty"`
+	PluginOpts string     `json:"plugin_opts,omitempty"`
+	TLS        *tls       `json:"tls,omitempty"`
+	Transport  *transport `json:"transport,omitempty"`
+}
+
+type tls struct {
+	Enabled    bool     `json:"enabled"`
+	ServerName string   `json:"server_name,omitempty"`
+	Insecure   bool     `json:"insecure,omitempty"`
+	UTLS       *utls    `json:"utls,omitempty"`
+	Reality    *reality `json:"reality,omitempty"`
+}
+
+type utls struct {
+	Enabled     bool   `json:"enabled"`
+	Fingerprint string `json:"fingerprint"`
+}
+
+type reality struct {
+	Enabled   bool   `json:"enabled"`
+	PublicKey string `json:"public_key"`
+	ShortID   string `json:"short_id,omitempty"`
+}
+
+type transport struct {
+	Type        string            `json:"type"`
+	Path        string            `json:"path,omitempty"`
+	Headers     map[string]string `json:"headers,omitempty"`
+	ServiceName string            `json:"service_name,omitempty"`
+}
+
+type route struct {
+	Final               string `json:"final"`
+	AutoDetectInterface bool   `json:"auto_detect_interface"`
+}
+
+// Renderer renders subscriptions into sing-box config
+type Renderer struct{}
+
+// ContentType implements subscription.Renderer
+func (r *Renderer) ContentType() string {
+	return "application/json; charset=utf-8"
+}
+
+// Render implements subscription.Renderer
+func (r *Renderer) Render(w io.Writer, sub *subscription.Subscription) error {
+	cfg, err := build(sub.Services)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(cfg)
+}
+
+func build(services []*models.Service) (*config, error) {
+	var proxies []outbound
+	var tags []string
+	names := subscription.NewNames(selectorTag, urltestTag, directTag)
+	for _, s := range services {
+		o, err := convert(s)
+		if err != nil {
+			return nil, err
+		}
+
+		// sing-box refuses duplicated tags
+		o.Tag = names.Unique(o.Tag)
+		proxies = append(proxies, *o)
+		tags = append(tags, o.Tag)
+	}
+
+	var cfg config
+	cfg.Log.Level = "info"
+	cfg.Inbounds = []inbound{{
+		Type:       "mixed",
+		Tag:        "mixed-in",
+		Listen:     "127.0.0.1",
+		ListenPort: 2080,
+	}}
+
+	selector := outbound{
+		Type:      "selector",
+		Tag:       selectorTag,
+		Outbounds: []string{directTag},
+		Default:   directTag,
+	}
+	if len(proxies) > 0 {
+		selector.Outbounds = append([]string{urltestTag}, tags...)
+		selector.Default = urltestTag
+	}
+	cfg.Outbounds = append(cfg.Outbounds, selector)
+
+	// urltest with no outbound is invalid
+	if len(proxies) > 0 {
+		cfg.Outbounds = append(cfg.Outbounds, outbound{
+			Type:      "urltest",
+			Tag:       urltestTag,
+			Outbounds: tags,
+			URL:       "https://www.gstatic.com/generate_204",
+			Interval:  "10m",
+		})
+	}
+	cfg.Outbounds = append(cfg.Outbounds, proxies...)
+	cfg.Outbounds = append(cfg.Outbounds, outbound{
+		Type: "direct",
+		Tag:  directTag,
+	})
+
+	cfg.Route.Final = selectorTag
+	cfg.Route.AutoDetectInterface = true
+	return &cfg, nil
+}
+
+func convert(s *models.Service) (*outbound, error) {
+	var o outbound
+	o.Tag = s.Name
+	o.Server = s.Host
+	o.ServerPort = s.Port
+
+	switch s.Protocol {
+	case "", models.ProtocolVmess:
+		o.Type = "vmess"
+		o.UUID = s.VmessUser.UUID
+		o.AlterID = s.AlterID
+		o.Security = s.VmessUser.Security
+		if o.Security == "" {
+			o.Security = "auto"
+		}
+	case models.ProtocolShadowsocks:
+		o.Type = "shadowsocks"
+		o.Method = s.Method
+		o.Password = s.ShadowsocksPassword()
+		o.Plugin = s.Plugin
+		o.PluginOpts = s.PluginOpts
+
+		// Shadowsocks has no stream settings
+		return &o, nil
+	case models.ProtocolTrojan:
+		o.Type = "trojan"
+		o.Password = s.TrojanUser.Password
+	case models.ProtocolVless:
+		o.Type = "vless"
+		o.UUID = s.VlessUser.UUID
+		o.Flow = s.Flow
+	default:
+		return nil, fmt.Errorf("Protocol %s of service %d not supported by sing-box renderer", s.Protocol, s.ID)
+	}
+
+	switch s.StreamSecurity {
+	case "", "none":
+	case "tls":
+		o.TLS = &tls{
+			Enabled:    true,
+			ServerName: s.ServerName,
+			Insecure:   s.AllowInsecure,
+		}
+		if s.Fingerprint != "" {
+			o.TLS.UTLS = &utls{Enabled: true, Fingerprint: s.Fingerprint}
+		}
+	case "reality":
+		if o.Type != "vless" {
+			return nil, fmt.Errorf("Reality of service %d requires vless", s.ID)
+		}
+		// Reality requires uTLS
+		fingerprint := s.Fingerprint
+		if fingerprint == "" {
+			fingerprint = "chrome"
+		}
+		o.TLS = &tls{
+			Enabled:    true,
+			ServerName: s.ServerName,
+			UTLS:       &utls{Enabled: true, Fingerprint: fingerprint},
+			Reality: &reality{
+				Enabled:   true,
+				PublicKey: s.RealityPublicKey,
+				ShortID:   s.RealityShortID,
+			},
+		}
+	default:
+		return nil, fmt.Errorf("Stream security %s of service %d not supported by sing-box renderer", s.StreamSecurity, s.ID)
+	}
+
+	switch s.TransportProtocol {
+	case "", "tcp":
+	case "ws":
+		o.Transport = &transport{
+			Type: "ws",
+			Path: s.WSPath,
+		}
+		if s.WSHost != "" {
+			o.Transport.Headers = map[string]string{"Host": s.WSHost}
+		}
+	case "grpc":
+		o.Transport = &transport{
+			Type:        "grpc",
+			ServiceName: s.GRPCServiceName,
+		}
+	default:
+		return nil, fmt.Errorf("Transport %s of service %d not supported by sing-box renderer", s.TransportProtocol, s.ID)
+	}
+	return &o, nil
+}
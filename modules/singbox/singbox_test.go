@@ -0,0 +1,150 @@
+package singbox
+
+import (
+	"testing"
+
+	"github.com/coolray-dev/raydash/models"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+
+	ws := models.Service{Name: "ws", Host: "example.com", Port: 443, Protocol: "vmess"}
+	ws.VmessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	ws.TransportProtocol = "ws"
+	ws.WSPath = "/ray"
+	ws.WSHost = "cdn.example.com"
+	ws.StreamSecurity = "tls"
+	ws.ServerName = "example.com"
+
+	grpc := models.Service{Name: "grpc", Host: "example.com", Port: 443}
+	grpc.TransportProtocol = "grpc"
+	grpc.GRPCServiceName = "raydash"
+
+	ss := models.Service{Name: "ss", Host: "example.com", Port: 8388, Protocol: "shadowsocks"}
+	ss.Method = "aes-128-gcm"
+	ss.ShadowsocksUser.Password = "password"
+
+	vless := models.Service{Name: "vless", Host: "example.com", Port: 443, Protocol: "vless"}
+	vless.VlessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	vless.Flow = models.FlowVision
+	vless.StreamSecurity = "reality"
+	vless.ServerName = "www.example.com"
+	vless.RealityPublicKey = "public"
+	vless.RealityShortID = "6ba85179e30d4fc2"
+
+	unknown := models.Service{Name: "unknown", Protocol: "unknown"}
+
+	cases := []struct {
+		Name    string
+		Service *models.Service
+		Want    *outbound
+		Error   bool
+	}{
+		{
+			"Vmess over ws with tls",
+			&ws,
+			&outbound{
+				Type:       "vmess",
+				Tag:        "ws",
+				Server:     "example.com",
+				ServerPort: 443,
+				UUID:       ws.VmessUser.UUID,
+				Security:   "auto",
+				TLS:        &tls{Enabled: true, ServerName: "example.com"},
+				Transport: &transport{
+					Type:    "ws",
+					Path:    "/ray",
+					Headers: map[string]string{"Host": "cdn.example.com"},
+				},
+			},
+			false,
+		},
+		{
+			"Vmess over grpc without tls",
+			&grpc,
+			&outbound{
+				Type:       "vmess",
+				Tag:        "grpc",
+				Server:     "example.com",
+				ServerPort: 443,
+				Security:   "auto",
+				Transport:  &transport{Type: "grpc", ServiceName: "raydash"},
+			},
+			false,
+		},
+		{
+			"Shadowsocks",
+			&ss,
+			&outbound{
+				Type:       "shadowsocks",
+				Tag:        "ss",
+				Server:     "example.com",
+				ServerPort: 8388,
+				Method:     "aes-128-gcm",
+				Password:   "password",
+			},
+			false,
+		},
+		{
+			"Vless with reality",
+			&vless,
+			&outbound{
+				Type:       "vless",
+				Tag:        "vless",
+				Server:     "example.com",
+				ServerPort: 443,
+				UUID:       vless.VlessUser.UUID,
+				Flow:       "xtls-rprx-vision",
+				TLS: &tls{
+					Enabled:    true,
+					ServerName: "www.example.com",
+					UTLS:       &utls{Enabled: true, Fingerprint: "chrome"},
+					Reality:    &reality{Enabled: true, PublicKey: "public", ShortID: "6ba85179e30d4fc2"},
+				},
+			},
+			false,
+		},
+		{"Unknown protocol", &unknown, nil, true},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assert := assertlib.New(t)
+			got, err := convert(c.Service)
+			if c.Error {
+				assert.NotNil(err)
+				return
+			}
+			assert.Nil(err)
+			assert.Equal(c.Want, got)
+		})
+	}
+}
+
+func TestBuild(t *testing.T) {
+	assert := assertlib.New(t)
+
+	// No services should still give a valid config
+	cfg, err := build(nil)
+	assert.Nil(err)
+	assert.Equal([]string{directTag}, cfg.Outbounds[0].Outbounds)
+	for _, o := range cfg.Outbounds {
+		assert.NotEqual("urltest", o.Type)
+	}
+
+	// Duplicated names get unique tags
+	a := models.Service{Name: "node"}
+	b := models.Service{Name: "node"}
+	cfg, err = build([]*models.Service{&a, &b})
+	assert.Nil(err)
+	assert.Equal([]string{urltestTag, "node", "node 2"}, cfg.Outbounds[0].Outbounds)
+	assert.Equal([]string{"node", "node 2"}, cfg.Outbounds[1].Outbounds)
+
+	// Suffixed names do not collide with real ones, nor names with group tags
+	c := models.Service{Name: "node 2"}
+	d := models.Service{Name: "auto"}
+	cfg, err = build([]*models.Service{&a, &b, &c, &d})
+	assert.Nil(err)
+	assert.Equal([]string{"node", "node 2", "node 2 2", "auto 2"}, cfg.Outbounds[1].Outbounds)
+}
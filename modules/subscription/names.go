@@ -0,0 +1,30 @@
+package subscription
+
+import "strconv"
+
+// Names gives proxies unique names within a subscription. Clients refuse
+// or silently merge duplicated names, and a proxy named like a group would
+// shadow the group.
+type Names struct {
+	taken map[string]bool
+}
+
+// NewNames returns a Names with reserved, such as group tags, already taken
+func NewNames(reserved ...string) *Names {
+	n := &Names{taken: make(map[string]bool, len(reserved))}
+	for _, r := range reserved {
+		n.taken[r] = true
+	}
+	return n
+}
+
+// Unique returns name if it is not taken yet, otherwise name with the
+// lowest numeric suffix that is free, and takes the result
+func (n *Names) Unique(name string) string {
+	unique := name
+	for i := 2; n.taken[unique]; i++ {
+		unique = name + " " + strconv.Itoa(i)
+	}
+	n.taken[unique] = true
+	return unique
+}
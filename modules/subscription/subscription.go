@@ -0,0 +1,73 @@
+package subscription
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+
+	"github.com/coolray-dev/raydash/models"
+)
+
+// ErrUnknownFormat is returned when no renderer registered under the requested name
+var ErrUnknownFormat = errors.New("Unknown subscription format")
+
+// Subscription holds everything a renderer needs to build a subscription
+type Subscription struct {
+	User     *models.User
+	Services []*models.Service
+	Nodes    map[uint64]*models.Node // nodes of services keyed by id
+	Profile  *models.Profile         // clash profile of the user, nil means using defaults
+}
+
+// Renderer renders a subscription into a client specific format
+type Renderer interface {
+	// ContentType returns the MIME type of the rendered body
+	ContentType() string
+
+	// Render writes the subscription body into w
+	Render(w io.Writer, sub *Subscription) error
+}
+
+var (
+	renderersMu sync.RWMutex
+	renderers   = make(map[string]Renderer)
+)
+
+// Register makes a renderer available under the provided name,
+// it panics if renderer is nil or name is registered twice
+func Register(name string, r Renderer) {
+	renderersMu.Lock()
+	defer renderersMu.Unlock()
+	if r == nil {
+		panic("subscription: Register renderer is nil")
+	}
+	if _, dup := renderers[name]; dup {
+		panic("subscription: Register called twice for renderer " + name)
+	}
+	renderers[name] = r
+}
+
+// Lookup returns the renderer registered under name
+func Lookup(name string) (Renderer, error) {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	r, ok := renderers[name]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownFormat, name)
+	}
+	return r, nil
+}
+
+// Formats returns a sorted list of registered format names
+func Formats() []string {
+	renderersMu.RLock()
+	defer renderersMu.RUnlock()
+	list := make([]string, 0, len(renderers))
+	for name := range renderers {
+		list = append(list, name)
+	}
+	sort.Strings(list)
+	return list
+}
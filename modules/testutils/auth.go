@@ -16,7 +16,7 @@ func SignAccessToken(user *models.User) string {
 }
 
 func SignRefreshToken(user *models.User) string {
-	token, err := jwt.SignRefreshToken(user)
+	token, err := jwt.SignRefreshToken(user, "", "")
 
 	if err != nil {
 		panic(err)
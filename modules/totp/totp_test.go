@@ -0,0 +1,53 @@
+package totp
+
+import (
+	"encoding/base32"
+	"testing"
+	"time"
+
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+// TestCode checks the SHA1 test vectors in appendix B of RFC 6238
+func TestCode(t *testing.T) {
+	secret := base32.StdEncoding.EncodeToString([]byte("12345678901234567890"))
+
+	cases := []struct {
+		Time int64
+		Code string // last 6 digits of the 8 digit vectors
+	}{
+		{59, "287082"},
+		{1111111109, "081804"},
+		{1111111111, "050471"},
+		{1234567890, "005924"},
+		{2000000000, "279037"},
+		{20000000000, "353130"},
+	}
+
+	for _, c := range cases {
+		code, err := Code(secret, Step(time.Unix(c.Time, 0)))
+		assertlib.Nil(t, err)
+		assertlib.Equal(t, c.Code, code, "time %d", c.Time)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	assert := assertlib.New(t)
+
+	secret, err := GenerateSecret()
+	assert.Nil(err)
+	now := time.Now()
+	code, _ := Code(secret, Step(now))
+
+	assert.Equal(Step(now), Validate(secret, code, now))
+	assert.Equal(Step(now), Validate(secret, code, now.Add(Period*time.Second)), "previous step is accepted for drift")
+	assert.Zero(Validate(secret, code, now.Add(3*Period*time.Second)))
+	assert.Zero(Validate(secret, "12345", now))
+
+	assert.Contains(URI("RayDash", "alice", secret), "otpauth://totp/RayDash:alice?")
+
+	codes, err := GenerateRecoveryCodes(10)
+	assert.Nil(err)
+	assert.Len(codes, 10)
+	assert.NotEqual(codes[0], codes[1])
+}
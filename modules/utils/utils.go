@@ -16,7 +16,8 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
-// Hash return sha256 checksum
+// Hash return sha256 checksum, it is no longer used for new passwords
+// which are hashed by modules/password
 func Hash(source string) string {
 	hashed := sha256.Sum256([]byte(source))
 	return base64.StdEncoding.EncodeToString(hashed[:])
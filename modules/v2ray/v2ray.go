@@ -0,0 +1,376 @@
+package v2ray
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+
+	"github.com/coolray-dev/raydash/models"
+)
+
+// Supported config formats
+const (
+	FormatV2ray = "v2ray"
+	FormatXray  = "xray"
+)
+
+// APIPort is the local port of the stats api inbound
+const APIPort = 10085
+
+// ErrUnknownFormat is returned when the format is neither v2ray nor xray
+var ErrUnknownFormat = errors.New("Unknown config format")
+
+// Config is a v2ray/xray json config
+type Config struct {
+	Log       logConfig  `json:"log"`
+	API       apiConfig  `json:"api"`
+	Stats     struct{}   `json:"stats"`
+	Policy    policy     `json:"policy"`
+	Inbounds  []inbound  `json:"inbounds"`
+	Outbounds []outbound `json:"outbounds"`
+	Routing   routing    `json:"routing"`
+}
+
+type logConfig struct {
+	LogLevel string `json:"loglevel"`
+}
+
+type apiConfig struct {
+	Tag      string   `json:"tag"`
+	Services []string `json:"services"`
+}
+
+type policy struct {
+	Levels map[string]levelPolicy `json:"levels"`
+	System systemPolicy           `json:"system"`
+}
+
+type levelPolicy struct {
+	StatsUserUplink   bool `json:"statsUserUplink"`
+	StatsUserDownlink bool `json:"statsUserDownlink"`
+}
+
+type systemPolicy struct {
+	StatsInboundUplink   bool `json:"statsInboundUplink"`
+	StatsInboundDownlink bool `json:"statsInboundDownlink"`
+}
+
+type inbound struct {
+	Tag            string          `json:"tag"`
+	Listen         string          `json:"listen"`
+	Port           uint            `json:"port"`
+	Protocol       string          `json:"protocol"`
+	Settings       inboundSettings `json:"settings"`
+	StreamSettings *streamSettings `json:"streamSettings,omitempty"`
+	Sniffing       *sniffing       `json:"sniffing,omitempty"`
+}
+
+// inboundSettings covers the settings of all inbound protocols we generate
+type inboundSettings struct {
+	Clients    []client `json:"clients,omitempty"`
+	Decryption string   `json:"decryption,omitempty"` // vless
+
+	// shadowsocks and dokodemo-door
+	Method   string `json:"method,omitempty"`
+	Password string `json:"password,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Network  string `json:"network,omitempty"`
+	Address  string `json:"address,omitempty"`
+}
+
+type client struct {
+	ID       string `json:"id,omitempty"`
+	AlterID  *uint  `json:"alterId,omitempty"`
+	Flow     string `json:"flow,omitempty"`
+	Password string `json:"password,omitempty"`
+	Method   string `json:"method,omitempty"`
+	Email    string `json:"email"`
+	Level    uint   `json:"level"`
+}
+
+type streamSettings struct {
+	Network         string           `json:"network"`
+	Security        string           `json:"security,omitempty"`
+	TLSSettings     *tlsSettings     `json:"tlsSettings,omitempty"`
+	RealitySettings *realitySettings `json:"realitySettings,omitempty"`
+	WSSettings      *wsSettings      `json:"wsSettings,omitempty"`
+	GRPCSettings    *grpcSettings    `json:"grpcSettings,omitempty"`
+}
+
+type tlsSettings struct {
+	ServerName   string        `json:"serverName,omitempty"`
+	Certificates []certificate `json:"certificates"`
+}
+
+type certificate struct {
+	CertificateFile string `json:"certificateFile"`
+	KeyFile         string `json:"keyFile"`
+}
+
+type realitySettings struct {
+	Show        bool     `json:"show"`
+	Dest        string   `json:"dest"`
+	Xver        uint     `json:"xver"`
+	ServerNames []string `json:"serverNames"`
+	PrivateKey  string   `json:"privateKey"`
+	ShortIDs    []string `json:"shortIds"`
+}
+
+type wsSettings struct {
+	Path    string            `json:"path"`
+	Headers map[string]string `json:"headers,omitempty"`
+}
+
+type grpcSettings struct {
+	ServiceName string `json:"serviceName"`
+}
+
+type sniffing struct {
+	Enabled      bool     `json:"enabled"`
+	DestOverride []string `json:"destOverride"`
+}
+
+type outbound struct {
+	Tag      string `json:"tag"`
+	Protocol string `json:"protocol"`
+}
+
+type routing struct {
+	Rules []rule `json:"rules"`
+}
+
+type rule struct {
+	Type        string   `json:"type"`
+	InboundTag  []string `json:"inboundTag"`
+	OutboundTag string   `json:"outboundTag"`
+}
+
+// Email returns the email of a service client in the config,
+// stats of the client are named after it
+func Email(s *models.Service) string {
+	return strconv.FormatUint(s.ID, 10)
+}
+
+// Build returns the config of node serving the services in format.
+// Single port nodes get one inbound holding every service as a client,
+// multi port nodes get one inbound per service.
+func Build(n *models.Node, services []*models.Service, format string) (*Config, error) {
+	if format != FormatV2ray && format != FormatXray {
+		return nil, ErrUnknownFormat
+	}
+
+	var cfg Config
+	cfg.Log.LogLevel = "warning"
+	cfg.API = apiConfig{
+		Tag:      "api",
+		Services: []string{"HandlerService", "StatsService"},
+	}
+	cfg.Policy = policy{
+		Levels: map[string]levelPolicy{
+			"0": {StatsUserUplink: true, StatsUserDownlink: true},
+		},
+		System: systemPolicy{StatsInboundUplink: true, StatsInboundDownlink: true},
+	}
+
+	if n.HasMultiPort {
+		for _, s := range services {
+			// Settings of multi port services live in the service itself
+			settings := models.Settings{
+				Protocol:           s.Protocol,
+				Listen:             n.Listen,
+				Port:               s.Port,
+				VmessSetting:       s.VmessSetting,
+				ShadowsocksSetting: s.ShadowsocksSetting,
+				RealitySetting:     n.RealitySetting,
+				TLSSetting:         n.TLSSetting,
+			}
+			in, err := buildInbound(n, &settings, []*models.Service{s}, format)
+			if err != nil {
+				return nil, err
+			}
+			in.Tag = "service-" + strconv.FormatUint(s.ID, 10)
+			cfg.Inbounds = append(cfg.Inbounds, *in)
+		}
+	} else {
+		in, err := buildInbound(n, &n.Settings, services, format)
+		if err != nil {
+			return nil, err
+		}
+		in.Tag = "proxy"
+		cfg.Inbounds = append(cfg.Inbounds, *in)
+	}
+
+	cfg.Inbounds = append(cfg.Inbounds, inbound{
+		Tag:      "api",
+		Listen:   "127.0.0.1",
+		Port:     APIPort,
+		Protocol: "dokodemo-door",
+		Settings: inboundSettings{Address: "127.0.0.1"},
+	})
+	cfg.Outbounds = []outbound{
+		{Tag: "direct", Protocol: "freedom"},
+		{Tag: "blocked", Protocol: "blackhole"},
+	}
+	cfg.Routing.Rules = []rule{{
+		Type:        "field",
+		InboundTag:  []string{"api"},
+		OutboundTag: "api",
+	}}
+	return &cfg, nil
+}
+
+func buildInbound(n *models.Node, settings *models.Settings, services []*models.Service, format string) (*inbound, error) {
+	in := inbound{
+		Listen:   settings.Listen,
+		Port:     settings.Port,
+		Protocol: settings.Protocol,
+		Sniffing: &sniffing{Enabled: true, DestOverride: []string{"http", "tls"}},
+	}
+	if in.Listen == "" {
+		in.Listen = "0.0.0.0"
+	}
+
+	switch settings.Protocol {
+	case "", models.ProtocolVmess:
+		in.Protocol = "vmess"
+		for _, s := range services {
+			c := client{ID: s.VmessUser.UUID, Email: Email(s)}
+			// xray only accepts AEAD, which is alterId 0
+			if format == FormatV2ray {
+				alterID := s.AlterID
+				c.AlterID = &alterID
+			}
+			in.Settings.Clients = append(in.Settings.Clients, c)
+		}
+	case models.ProtocolVless:
+		in.Settings.Decryption = "none"
+		for _, s := range services {
+			if s.Flow != "" && format != FormatXray {
+				return nil, fmt.Errorf("Flow %s of service %d requires xray", s.Flow, s.ID)
+			}
+			in.Settings.Clients = append(in.Settings.Clients, client{
+				ID:    s.VlessUser.UUID,
+				Flow:  s.Flow,
+				Email: Email(s),
+			})
+		}
+	case models.ProtocolTrojan:
+		for _, s := range services {
+			in.Settings.Clients = append(in.Settings.Clients, client{
+				Password: s.TrojanUser.Password,
+				Email:    Email(s),
+			})
+		}
+	case models.ProtocolShadowsocks:
+		// Plugins are not part of v2ray, operators run them in front of it
+		return buildShadowsocks(&in, n, settings, services, format)
+	default:
+		return nil, fmt.Errorf("Unsupported protocol %q", settings.Protocol)
+	}
+
+	stream, err := buildStream(settings, format)
+	if err != nil {
+		return nil, err
+	}
+	in.StreamSettings = stream
+	return &in, nil
+}
+
+func buildShadowsocks(in *inbound, n *models.Node, settings *models.Settings, services []*models.Service, format string) (*inbound, error) {
+	in.Settings.Network = "tcp"
+	if n.HasUDP {
+		in.Settings.Network = "tcp,udp"
+	}
+
+	if format == FormatV2ray {
+		// v2ray takes a single user per shadowsocks inbound
+		if settings.ShadowsocksSetting.Is2022() {
+			return nil, fmt.Errorf("Method %s requires xray", settings.Method)
+		}
+		if len(services) > 1 {
+			return nil, fmt.Errorf("v2ray supports only one shadowsocks user per inbound, node %d has %d", n.ID, len(services))
+		}
+		in.Settings.Method = settings.Method
+		if len(services) == 1 {
+			in.Settings.Password = services[0].ShadowsocksUser.Password
+			in.Settings.Email = Email(services[0])
+		}
+		return in, nil
+	}
+
+	// xray puts server key of 2022 methods at inbound level and methods
+	// of other ciphers in clients
+	if settings.ShadowsocksSetting.Is2022() {
+		in.Settings.Method = settings.Method
+		in.Settings.Password = settings.ServerKey
+		for _, s := range services {
+			in.Settings.Clients = append(in.Settings.Clients, client{
+				Password: s.ShadowsocksUser.Password,
+				Email:    Email(s),
+			})
+		}
+		return in, nil
+	}
+	for _, s := range services {
+		in.Settings.Clients = append(in.Settings.Clients, client{
+			Method:   settings.Method,
+			Password: s.ShadowsocksUser.Password,
+			Email:    Email(s),
+		})
+	}
+	return in, nil
+}
+
+func buildStream(settings *models.Settings, format string) (*streamSettings, error) {
+	var stream streamSettings
+
+	stream.Network = settings.TransportProtocol
+	switch stream.Network {
+	case "":
+		stream.Network = "tcp"
+	case "tcp":
+	case "ws":
+		stream.WSSettings = &wsSettings{Path: settings.WSPath}
+		if stream.WSSettings.Path == "" {
+			stream.WSSettings.Path = "/"
+		}
+		if settings.WSHost != "" {
+			stream.WSSettings.Headers = map[string]string{"Host": settings.WSHost}
+		}
+	case "grpc":
+		stream.GRPCSettings = &grpcSettings{ServiceName: settings.GRPCServiceName}
+	default:
+		return nil, fmt.Errorf("Unsupported transport %q", settings.TransportProtocol)
+	}
+
+	switch settings.StreamSecurity {
+	case "", "none":
+		stream.Security = "none"
+	case "tls":
+		stream.Security = "tls"
+		stream.TLSSettings = &tlsSettings{
+			ServerName: settings.ServerName,
+			Certificates: []certificate{{
+				CertificateFile: settings.CertificateFile,
+				KeyFile:         settings.KeyFile,
+			}},
+		}
+	case "reality":
+		if format != FormatXray {
+			return nil, fmt.Errorf("Reality requires xray")
+		}
+		stream.Security = "reality"
+		stream.RealitySettings = &realitySettings{
+			Dest:        settings.RealitySetting.Dest,
+			ServerNames: []string{settings.ServerName},
+			PrivateKey:  settings.RealitySetting.PrivateKey,
+			ShortIDs:    []string{settings.RealityShortID},
+		}
+		if stream.RealitySettings.Dest == "" {
+			stream.RealitySettings.Dest = settings.ServerName + ":443"
+		}
+	default:
+		return nil, fmt.Errorf("Unsupported stream security %q", settings.StreamSecurity)
+	}
+	return &stream, nil
+}
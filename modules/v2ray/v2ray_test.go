@@ -0,0 +1,112 @@
+package v2ray
+
+import (
+	"testing"
+
+	"github.com/coolray-dev/raydash/models"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestBuild(t *testing.T) {
+
+	vmessNode := models.Node{}
+	vmessNode.Port = 443
+	vmessNode.TransportProtocol = "ws"
+	vmessNode.StreamSecurity = "tls"
+	vmessNode.CertificateFile = "/etc/ssl/cert.pem"
+	vmessNode.KeyFile = "/etc/ssl/key.pem"
+	vmess := models.Service{}
+	vmess.ID = 1
+	vmess.VmessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+
+	vlessNode := models.Node{}
+	vlessNode.Protocol = models.ProtocolVless
+	vlessNode.Port = 443
+	vlessNode.StreamSecurity = "reality"
+	vlessNode.ServerName = "www.example.com"
+	vlessNode.RealitySetting.PrivateKey = "private"
+	vlessNode.RealityShortID = "6ba85179e30d4fc2"
+	vless := models.Service{Protocol: models.ProtocolVless}
+	vless.ID = 2
+	vless.VlessUser = models.VlessUser{UUID: vmess.VmessUser.UUID, Flow: models.FlowVision}
+
+	ssNode := models.Node{}
+	ssNode.Protocol = models.ProtocolShadowsocks
+	ssNode.Port = 8388
+	ssNode.Method = "2022-blake3-aes-128-gcm"
+	ssNode.ServerKey = "server"
+	ss := models.Service{Protocol: models.ProtocolShadowsocks}
+	ss.ID = 3
+	ss.ShadowsocksUser.Password = "user"
+
+	t.Run("Vmess", func(t *testing.T) {
+		assert := assertlib.New(t)
+		cfg, err := Build(&vmessNode, []*models.Service{&vmess}, FormatV2ray)
+		assert.Nil(err)
+		assert.Len(cfg.Inbounds, 2)
+		in := cfg.Inbounds[0]
+		assert.Equal("vmess", in.Protocol)
+		assert.Equal("0.0.0.0", in.Listen)
+		assert.Equal(uint(0), *in.Settings.Clients[0].AlterID)
+		assert.Equal("1", in.Settings.Clients[0].Email)
+		assert.Equal("/", in.StreamSettings.WSSettings.Path)
+		assert.Equal("/etc/ssl/cert.pem", in.StreamSettings.TLSSettings.Certificates[0].CertificateFile)
+		assert.Equal("api", cfg.Inbounds[1].Tag)
+
+		// xray drops alterId
+		cfg, err = Build(&vmessNode, []*models.Service{&vmess}, FormatXray)
+		assert.Nil(err)
+		assert.Nil(cfg.Inbounds[0].Settings.Clients[0].AlterID)
+	})
+
+	t.Run("Vless with reality", func(t *testing.T) {
+		assert := assertlib.New(t)
+		_, err := Build(&vlessNode, []*models.Service{&vless}, FormatV2ray)
+		assert.NotNil(err)
+
+		cfg, err := Build(&vlessNode, []*models.Service{&vless}, FormatXray)
+		assert.Nil(err)
+		in := cfg.Inbounds[0]
+		assert.Equal("none", in.Settings.Decryption)
+		assert.Equal(models.FlowVision, in.Settings.Clients[0].Flow)
+		assert.Equal(&realitySettings{
+			Dest:        "www.example.com:443",
+			ServerNames: []string{"www.example.com"},
+			PrivateKey:  "private",
+			ShortIDs:    []string{"6ba85179e30d4fc2"},
+		}, in.StreamSettings.RealitySettings)
+	})
+
+	t.Run("Shadowsocks 2022", func(t *testing.T) {
+		assert := assertlib.New(t)
+		_, err := Build(&ssNode, []*models.Service{&ss}, FormatV2ray)
+		assert.NotNil(err)
+
+		cfg, err := Build(&ssNode, []*models.Service{&ss}, FormatXray)
+		assert.Nil(err)
+		in := cfg.Inbounds[0]
+		assert.Equal("server", in.Settings.Password)
+		assert.Equal([]client{{Password: "user", Email: "3"}}, in.Settings.Clients)
+		assert.Nil(in.StreamSettings)
+	})
+
+	t.Run("Multi port", func(t *testing.T) {
+		assert := assertlib.New(t)
+		node := models.Node{HasMultiPort: true}
+		a := vmess
+		a.Port = 10000
+		b := vmess
+		b.ID = 4
+		b.Port = 10001
+		cfg, err := Build(&node, []*models.Service{&a, &b}, FormatV2ray)
+		assert.Nil(err)
+		assert.Len(cfg.Inbounds, 3)
+		assert.Equal("service-4", cfg.Inbounds[1].Tag)
+		assert.Equal(uint(10001), cfg.Inbounds[1].Port)
+	})
+
+	t.Run("Unknown format", func(t *testing.T) {
+		_, err := Build(&vmessNode, nil, "clash")
+		assertlib.Equal(t, ErrUnknownFormat, err)
+	})
+}
@@ -0,0 +1,188 @@
+package v2rayn
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io"
+	"net"
+	"net/url"
+	"strconv"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/subscription"
+)
+
+func init() {
+	subscription.Register("v2rayn", &Renderer{})
+}
+
+// vmessLink is the json payload of a v2rayN style vmess:// share link
+type vmessLink struct {
+	Version  string `json:"v"`
+	Name     string `json:"ps"`
+	Address  string `json:"add"`
+	Port     string `json:"port"`
+	UUID     string `json:"id"`
+	AlterID  string `json:"aid"`
+	Security string `json:"scy"`
+	Network  string `json:"net"`
+	Type     string `json:"type"`
+	Host     string `json:"host"`
+	Path     string `json:"path"`
+	TLS      string `json:"tls"`
+	SNI      string `json:"sni"`
+}
+
+// Renderer renders subscriptions into a base64 encoded list of share links
+type Renderer struct{}
+
+// ContentType implements subscription.Renderer
+func (r *Renderer) ContentType() string {
+	return "text/plain; charset=utf-8"
+}
+
+// Render implements subscription.Renderer
+func (r *Renderer) Render(w io.Writer, sub *subscription.Subscription) error {
+	var links bytes.Buffer
+	for _, s := range sub.Services {
+		var link string
+		var err error
+		switch s.Protocol {
+		case "", models.ProtocolVmess:
+			link, err = convert(s)
+		case models.ProtocolShadowsocks:
+			link = convertShadowsocks(s)
+		case models.ProtocolTrojan:
+			link = convertURI("trojan", s.TrojanUser.Password, s, nil)
+		case models.ProtocolVless:
+			query := url.Values{"encryption": {"none"}}
+			if s.Flow != "" {
+				query.Set("flow", s.Flow)
+			}
+			link = convertURI("vless", s.VlessUser.UUID, s, query)
+		default:
+			// v2rayN share links of other protocols are not supported yet
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		links.WriteString(link)
+		links.WriteString("\n")
+	}
+	_, err := io.WriteString(w, base64.StdEncoding.EncodeToString(links.Bytes()))
+	return err
+}
+
+func convert(s *models.Service) (string, error) {
+	var link vmessLink
+	link.Version = "2"
+	link.Name = s.Name
+	link.Address = s.Host
+	link.Port = strconv.Itoa(int(s.Port))
+	link.UUID = s.VmessUser.UUID
+	link.AlterID = strconv.Itoa(int(s.AlterID))
+	link.Security = s.VmessUser.Security
+	if link.Security == "" {
+		link.Security = "auto"
+	}
+	link.Network = s.TransportProtocol
+	if link.Network == "" {
+		link.Network = "tcp"
+	}
+	link.Type = "none"
+	switch link.Network {
+	case "ws":
+		link.Host = s.WSHost
+		link.Path = s.WSPath
+		if link.Path == "" {
+			link.Path = "/"
+		}
+	case "grpc":
+		link.Path = s.GRPCServiceName
+	}
+	if s.StreamSecurity == "tls" {
+		link.TLS = "tls"
+		link.SNI = s.ServerName
+	}
+
+	j, err := json.Marshal(&link)
+	if err != nil {
+		return "", err
+	}
+	return "vmess://" + base64.StdEncoding.EncodeToString(j), nil
+}
+
+// convertShadowsocks builds a SIP002 ss:// link
+func convertShadowsocks(s *models.Service) string {
+	var userinfo string
+	if s.ShadowsocksSetting.Is2022() {
+		// SIP022 requires percent encoding instead of base64
+		userinfo = url.QueryEscape(s.Method) + ":" + url.QueryEscape(s.ShadowsocksPassword())
+	} else {
+		userinfo = base64.RawURLEncoding.EncodeToString([]byte(s.Method + ":" + s.ShadowsocksPassword()))
+	}
+	link := "ss://" + userinfo + "@" + net.JoinHostPort(s.Host, strconv.Itoa(int(s.Port)))
+	if s.Plugin != "" {
+		plugin := s.Plugin
+		if s.PluginOpts != "" {
+			plugin += ";" + s.PluginOpts
+		}
+		link += "/?plugin=" + url.QueryEscape(plugin)
+	}
+	return link + "#" + url.PathEscape(s.Name)
+}
+
+// convertURI builds the de facto standard share link used by xray clients
+// for vless and trojan, query carries protocol specific parameters
+func convertURI(scheme string, userinfo string, s *models.Service, query url.Values) string {
+	if query == nil {
+		query = url.Values{}
+	}
+	network := s.TransportProtocol
+	if network == "" {
+		network = "tcp"
+	}
+	query.Set("type", network)
+	switch network {
+	case "ws":
+		path := s.WSPath
+		if path == "" {
+			path = "/"
+		}
+		query.Set("path", path)
+		if s.WSHost != "" {
+			query.Set("host", s.WSHost)
+		}
+	case "grpc":
+		query.Set("serviceName", s.GRPCServiceName)
+	}
+
+	switch s.StreamSecurity {
+	case "tls":
+		query.Set("security", "tls")
+		if s.AllowInsecure {
+			query.Set("allowInsecure", "1")
+		}
+	case "reality":
+		query.Set("security", "reality")
+		query.Set("fp", "chrome") // reality requires a client fingerprint
+		query.Set("pbk", s.RealityPublicKey)
+		if s.RealityShortID != "" {
+			query.Set("sid", s.RealityShortID)
+		}
+	default:
+		query.Set("security", "none")
+	}
+	if s.ServerName != "" {
+		query.Set("sni", s.ServerName)
+	}
+	if s.Fingerprint != "" {
+		query.Set("fp", s.Fingerprint)
+	}
+
+	// url.User escapes @ too, which PathEscape leaves in place
+	return scheme + "://" + url.User(userinfo).String() + "@" + net.JoinHostPort(s.Host, strconv.Itoa(int(s.Port))) +
+		"?" + query.Encode() + "#" + url.PathEscape(s.Name)
+}
@@ -0,0 +1,150 @@
+package v2rayn
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/coolray-dev/raydash/models"
+	"github.com/coolray-dev/raydash/modules/subscription"
+	assertlib "github.com/stretchr/testify/assert"
+)
+
+func TestConvert(t *testing.T) {
+	assert := assertlib.New(t)
+
+	ws := models.Service{Name: "ws #1", Host: "example.com", Port: 443, Protocol: "vmess"}
+	ws.VmessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	ws.TransportProtocol = "ws"
+	ws.WSHost = "cdn.example.com"
+	ws.StreamSecurity = "tls"
+	ws.ServerName = "example.com"
+
+	link, err := convert(&ws)
+	assert.Nil(err)
+	assert.True(strings.HasPrefix(link, "vmess://"))
+
+	// Payload is standard base64 of the json v2rayN expects
+	payload, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(link, "vmess://"))
+	assert.Nil(err)
+	var got vmessLink
+	assert.Nil(json.Unmarshal(payload, &got))
+	assert.Equal(vmessLink{
+		Version:  "2",
+		Name:     "ws #1",
+		Address:  "example.com",
+		Port:     "443",
+		UUID:     ws.VmessUser.UUID,
+		AlterID:  "0",
+		Security: "auto",
+		Network:  "ws",
+		Type:     "none",
+		Host:     "cdn.example.com",
+		Path:     "/",
+		TLS:      "tls",
+		SNI:      "example.com",
+	}, got)
+}
+
+func TestConvertShadowsocks(t *testing.T) {
+	ss := models.Service{Name: "ss #1/a b", Host: "example.com", Port: 8388, Protocol: "shadowsocks"}
+	ss.Method = "aes-128-gcm"
+	ss.ShadowsocksUser.Password = "password"
+
+	obfs := ss
+	obfs.Plugin = "obfs-local"
+	obfs.PluginOpts = "obfs=http;obfs-host=example.com"
+
+	ss2022 := ss
+	ss2022.Method = "2022-blake3-aes-128-gcm"
+	ss2022.ServerKey = "a+b/c="
+	ss2022.ShadowsocksUser.Password = "d="
+
+	ipv6 := ss
+	ipv6.Host = "2001:db8::1"
+
+	cases := []struct {
+		Name    string
+		Service *models.Service
+		Want    string
+	}{
+		{"Userinfo in base64", &ss, "ss://YWVzLTEyOC1nY206cGFzc3dvcmQ@example.com:8388#ss%20%231%2Fa%20b"},
+		{"Plugin", &obfs, "ss://YWVzLTEyOC1nY206cGFzc3dvcmQ@example.com:8388/?plugin=obfs-local%3Bobfs%3Dhttp%3Bobfs-host%3Dexample.com#ss%20%231%2Fa%20b"},
+		{"2022 userinfo percent encoded", &ss2022, "ss://2022-blake3-aes-128-gcm:a%2Bb%2Fc%3D%3Ad%3D@example.com:8388#ss%20%231%2Fa%20b"},
+		{"IPv6 host", &ipv6, "ss://YWVzLTEyOC1nY206cGFzc3dvcmQ@[2001:db8::1]:8388#ss%20%231%2Fa%20b"},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assertlib.Equal(t, c.Want, convertShadowsocks(c.Service))
+		})
+	}
+}
+
+func TestConvertURI(t *testing.T) {
+	trojan := models.Service{Name: "trojan #1", Host: "example.com", Port: 443, Protocol: "trojan"}
+	trojan.TransportProtocol = "grpc"
+	trojan.GRPCServiceName = "raydash"
+	trojan.StreamSecurity = "tls"
+	trojan.ServerName = "example.com"
+	trojan.TrojanUser.Password = "p@ss/word"
+
+	vless := models.Service{Name: "vless", Host: "example.com", Port: 443, Protocol: "vless"}
+	vless.VlessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	vless.Flow = models.FlowVision
+	vless.StreamSecurity = "reality"
+	vless.ServerName = "www.example.com"
+	vless.RealityPublicKey = "public"
+	vless.RealityShortID = "6ba85179e30d4fc2"
+
+	cases := []struct {
+		Name string
+		Got  string
+		Want string
+	}{
+		{
+			"Trojan over grpc",
+			convertURI("trojan", trojan.TrojanUser.Password, &trojan, nil),
+			"trojan://p%40ss%2Fword@example.com:443?security=tls&serviceName=raydash&sni=example.com&type=grpc#trojan%20%231",
+		},
+		{
+			"Vless with reality",
+			convertURI("vless", vless.VlessUser.UUID, &vless, url.Values{"encryption": {"none"}, "flow": {vless.Flow}}),
+			"vless://b831381d-6324-4d53-ad4f-8cda48b30811@example.com:443?encryption=none&flow=xtls-rprx-vision&fp=chrome&pbk=public&security=reality&sid=6ba85179e30d4fc2&sni=www.example.com&type=tcp#vless",
+		},
+	}
+
+	for _, c := range cases {
+		t.Run(c.Name, func(t *testing.T) {
+			assertlib.Equal(t, c.Want, c.Got)
+		})
+	}
+}
+
+func TestRender(t *testing.T) {
+	assert := assertlib.New(t)
+
+	vmess := models.Service{Name: "vmess", Host: "example.com", Port: 443}
+	vmess.VmessUser.UUID = "b831381d-6324-4d53-ad4f-8cda48b30811"
+	ss := models.Service{Name: "ss", Host: "example.com", Port: 8388, Protocol: "shadowsocks"}
+	ss.Method = "aes-128-gcm"
+	ss.ShadowsocksUser.Password = "password"
+	unsupported := models.Service{Name: "unknown", Protocol: "unknown"}
+
+	var b bytes.Buffer
+	r := &Renderer{}
+	assert.Nil(r.Render(&b, &subscription.Subscription{
+		Services: []*models.Service{&vmess, &unsupported, &ss},
+	}))
+
+	// Output is the base64 of one link per line, unsupported protocols are skipped
+	links, err := base64.StdEncoding.DecodeString(b.String())
+	assert.Nil(err)
+	lines := strings.Split(strings.TrimSuffix(string(links), "\n"), "\n")
+	assert.Len(lines, 2)
+	assert.True(strings.HasPrefix(lines[0], "vmess://"))
+	assert.Equal(convertShadowsocks(&ss), lines[1])
+}